@@ -0,0 +1,392 @@
+package clock
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calendar is a business days calendar with weekends, holidays and business hours in given location.
+type Calendar struct {
+	loc      *time.Location
+	weekend  uint8
+	holidays []Holiday
+	workdays []Holiday
+	// Business hours as offsets from midnight. Zero close means whole day.
+	open, close time.Duration
+
+	mux   sync.RWMutex
+	cache map[int]map[uint16]bool
+}
+
+// calendarMaxGap limits search of business day: calendar without business days in this number of consecutive days
+// (e.g. all weekdays are weekend) has no business days at all.
+const calendarMaxGap = 3660
+
+// NewCalendar makes new calendar with Saturday and Sunday as weekend.
+// Nil location means time.Local.
+func NewCalendar(loc *time.Location) *Calendar {
+	if loc == nil {
+		loc = time.Local
+	}
+	c := &Calendar{loc: loc}
+	c.SetWeekend(time.Saturday, time.Sunday)
+	return c
+}
+
+// SetWeekend sets weekend days.
+func (c *Calendar) SetWeekend(days ...time.Weekday) *Calendar {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.weekend = 0
+	for _, wd := range days {
+		c.weekend |= 1 << uint(wd)
+	}
+	return c
+}
+
+// AddHoliday adds holiday rules.
+func (c *Calendar) AddHoliday(rules ...Holiday) *Calendar {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.holidays = append(c.holidays, rules...)
+	c.cache = nil
+	return c
+}
+
+// AddWorkday adds working days rules. Workday overrides weekend and holidays (e.g. transferred working Saturday).
+func (c *Calendar) AddWorkday(rules ...Holiday) *Calendar {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.workdays = append(c.workdays, rules...)
+	c.cache = nil
+	return c
+}
+
+// SetBusinessHours sets business hours window as offsets from local midnight, e.g. 9*time.Hour and 18*time.Hour.
+func (c *Calendar) SetBusinessHours(from, till time.Duration) *Calendar {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.open, c.close = from, till
+	return c
+}
+
+// Location returns calendar location.
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// IsHoliday checks if t falls on holiday.
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	y, m, d := t.In(c.loc).Date()
+	hol, ok := c.lookup(y, m, d)
+	return ok && hol
+}
+
+// IsBusinessDay checks if t falls on working day.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.loc)
+	y, m, d := t.Date()
+	return c.isBusinessDate(y, m, d, t.Weekday())
+}
+
+// AddBusinessDays moves t by n business days keeping wall clock. Non-business t moves to business day first only
+// if n isn't zero, e.g. Saturday + 1 business day = Monday.
+// Returns zero time if calendar has no business days.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	t = t.In(c.loc)
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	r, gap := t, 0
+	for k := step; n > 0; k += step {
+		// Shift from origin t each time to keep wall clock over DST gaps.
+		if r = AddUnits(t, k, Day); c.IsBusinessDay(r) {
+			n, gap = n-1, 0
+		} else if gap++; gap > calendarMaxGap {
+			return time.Time{}
+		}
+	}
+	return r
+}
+
+// BusinessDaysBetween returns number of business days in dates range [from, to).
+// Result is negative if to is before from.
+func (c *Calendar) BusinessDaysBetween(from, to time.Time) int {
+	sign := 1
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+	from, to = from.In(c.loc), to.In(c.loc)
+	y0, m0, d0 := from.Date()
+	y1, m1, d1 := to.Date()
+	days := civilDays(y1, m1, d1) - civilDays(y0, m0, d0)
+	var r int
+	for i := 0; i < days; i++ {
+		t := time.Date(y0, m0, d0+i, 0, 0, 0, 0, time.UTC)
+		y, m, d := t.Date()
+		if c.isBusinessDate(y, m, d, t.Weekday()) {
+			r++
+		}
+	}
+	return r * sign
+}
+
+// BusinessHours returns business hours window of the day containing t. False means t isn't a business day.
+func (c *Calendar) BusinessHours(t time.Time) (Interval, bool) {
+	if !c.IsBusinessDay(t) {
+		return Interval{}, false
+	}
+	return c.window(t), true
+}
+
+// IsBusinessTime checks if t falls into business hours of business day.
+func (c *Calendar) IsBusinessTime(t time.Time) bool {
+	w, ok := c.BusinessHours(t)
+	return ok && w.Contains(t)
+}
+
+// NextBusinessTime returns t if it falls into business hours or the opening of the next business hours window.
+// It answers "when the request submitted at t will be processed", e.g. Friday 19:00 -> Monday 09:00.
+func (c *Calendar) NextBusinessTime(t time.Time) time.Time {
+	t = t.In(c.loc)
+	if w, ok := c.BusinessHours(t); ok {
+		if w.Contains(t) {
+			return t
+		}
+		if t.Before(w.Start) {
+			return w.Start
+		}
+	}
+	return c.NextBusinessDay(t)
+}
+
+// NextBusinessDay returns the opening of the first business day after the day containing t.
+// Returns zero time if calendar has no business days.
+func (c *Calendar) NextBusinessDay(t time.Time) time.Time {
+	t = StartOf(t, Day, c.loc)
+	for i := 0; i <= calendarMaxGap; i++ {
+		t = startOfShift(t, 1, Day, c.loc)
+		if c.IsBusinessDay(t) {
+			return c.window(t).Start
+		}
+	}
+	return time.Time{}
+}
+
+// ProcessingDay returns the start of business day t belongs to considering given cutoff (offset from local midnight).
+// Events at or after cutoff and events on non-business days belong to the next business day.
+func (c *Calendar) ProcessingDay(t time.Time, cutoff time.Duration) time.Time {
+	t = t.In(c.loc)
+	start := StartOf(t, Day, c.loc)
+	if c.IsBusinessDay(t) && t.Sub(start) < cutoff {
+		return start
+	}
+	next := c.NextBusinessDay(t)
+	if next.IsZero() {
+		return next
+	}
+	return StartOf(next, Day, c.loc)
+}
+
+func (c *Calendar) window(t time.Time) Interval {
+	c.mux.RLock()
+	from, till := c.open, c.close
+	c.mux.RUnlock()
+	y, m, d := t.In(c.loc).Date()
+	if till == 0 {
+		start := dateIn(y, m, d, 0, 0, 0, 0, c.loc)
+		return Interval{Start: start, End: startOfShift(start, 1, Day, c.loc)}
+	}
+	return Interval{
+		Start: dateAt(y, m, d, from, c.loc),
+		End:   dateAt(y, m, d, till, c.loc),
+	}
+}
+
+// dateAt returns time of day tod (offset from midnight) of given date. Time of day is split into components, since
+// nanoseconds of day overflow int on 32-bit platforms.
+func dateAt(y int, m time.Month, d int, tod time.Duration, loc *time.Location) time.Time {
+	return dateIn(y, m, d, int(tod/time.Hour), int(tod%time.Hour/time.Minute), int(tod%time.Minute/time.Second),
+		int(tod%time.Second), loc)
+}
+
+func (c *Calendar) isBusinessDate(y int, m time.Month, d int, wd time.Weekday) bool {
+	if hol, ok := c.lookup(y, m, d); ok {
+		return !hol
+	}
+	c.mux.RLock()
+	weekend := c.weekend
+	c.mux.RUnlock()
+	return weekend&(1<<uint(wd)) == 0
+}
+
+// lookup checks date in holidays/workdays. True means holiday, false means workday, ok false means regular day.
+func (c *Calendar) lookup(y int, m time.Month, d int) (hol bool, ok bool) {
+	key := uint16(m)<<5 | uint16(d)
+	c.mux.RLock()
+	yc, found := c.cache[y]
+	c.mux.RUnlock()
+	if !found {
+		c.mux.Lock()
+		if yc, found = c.cache[y]; !found {
+			yc = make(map[uint16]bool)
+			for _, h := range c.holidays {
+				if hm, hd, hok := h.Date(y); hok {
+					yc[uint16(hm)<<5|uint16(hd)] = true
+				}
+			}
+			for _, h := range c.workdays {
+				if hm, hd, hok := h.Date(y); hok {
+					yc[uint16(hm)<<5|uint16(hd)] = false
+				}
+			}
+			if c.cache == nil {
+				c.cache = make(map[int]map[uint16]bool)
+			}
+			c.cache[y] = yc
+		}
+		c.mux.Unlock()
+	}
+	hol, ok = yc[key]
+	return
+}
+
+// calendarDef is JSON definition of calendar.
+type calendarDef struct {
+	Location string   `json:"location"`
+	Weekend  []string `json:"weekend"`
+	Hours    []string `json:"hours"`
+	Holidays []string `json:"holidays"`
+	Workdays []string `json:"workdays"`
+}
+
+// LoadCalendar loads calendar from definition file. See ParseCalendar for format details.
+func LoadCalendar(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCalendar(data)
+}
+
+// ParseCalendar parses calendar definition in JSON or text format.
+//
+// JSON format:
+//
+//	{"location": "America/New_York", "weekend": ["sat", "sun"], "hours": ["09:30", "16:00"],
+//	 "holidays": ["01-01", "last mon may", "easter-2"], "workdays": ["2024-11-02"]}
+//
+// Text format contains one directive per line, "#" starts comment:
+//
+//	location America/New_York
+//	weekend sat sun
+//	hours 09:30 16:00
+//	holiday 01-01
+//	holiday last monday of may
+//	workday 2024-11-02
+//
+// Holiday rules syntax is described in ParseHoliday.
+func ParseCalendar(data []byte) (*Calendar, error) {
+	var def calendarDef
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &def); err != nil {
+			return nil, err
+		}
+	} else {
+		scr := bufio.NewScanner(bytes.NewReader(data))
+		for scr.Scan() {
+			line := scr.Text()
+			if i := strings.IndexByte(line, '#'); i != -1 {
+				line = line[:i]
+			}
+			if line = strings.TrimSpace(line); len(line) == 0 {
+				continue
+			}
+			kw, tail := line, ""
+			if i := strings.IndexAny(line, " \t"); i != -1 {
+				kw, tail = line[:i], strings.TrimSpace(line[i+1:])
+			}
+			switch strings.ToLower(kw) {
+			case "location":
+				def.Location = tail
+			case "weekend":
+				if def.Weekend == nil {
+					def.Weekend = []string{}
+				}
+				def.Weekend = append(def.Weekend, strings.Fields(tail)...)
+			case "hours":
+				def.Hours = strings.Fields(tail)
+			case "holiday":
+				def.Holidays = append(def.Holidays, tail)
+			case "workday":
+				def.Workdays = append(def.Workdays, tail)
+			default:
+				return nil, ErrBadCalendar
+			}
+		}
+		if err := scr.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return def.build()
+}
+
+func (def *calendarDef) build() (*Calendar, error) {
+	loc := time.Local
+	if len(def.Location) > 0 {
+		var err error
+		if loc, err = LoadLocation(def.Location); err != nil {
+			return nil, err
+		}
+	}
+	c := NewCalendar(loc)
+	if def.Weekend != nil {
+		days := make([]time.Weekday, 0, len(def.Weekend))
+		for _, s := range def.Weekend {
+			wd, ok := lookupWeekday(strings.ToLower(s))
+			if !ok {
+				return nil, ErrBadCalendar
+			}
+			days = append(days, wd)
+		}
+		c.SetWeekend(days...)
+	}
+	if len(def.Hours) > 0 {
+		if len(def.Hours) != 2 {
+			return nil, ErrBadCalendar
+		}
+		var hours [2]time.Duration
+		for i, s := range def.Hours {
+			t, err := time.Parse("15:04", s)
+			if err != nil {
+				return nil, ErrBadCalendar
+			}
+			hours[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		}
+		if hours[1] <= hours[0] {
+			return nil, ErrBadCalendar
+		}
+		c.SetBusinessHours(hours[0], hours[1])
+	}
+	for _, s := range def.Holidays {
+		h, err := ParseHoliday(s)
+		if err != nil {
+			return nil, err
+		}
+		c.AddHoliday(h)
+	}
+	for _, s := range def.Workdays {
+		h, err := ParseHoliday(s)
+		if err != nil {
+			return nil, err
+		}
+		c.AddWorkday(h)
+	}
+	return c, nil
+}
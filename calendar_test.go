@@ -0,0 +1,176 @@
+package clock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const calendarUS = `
+# NYSE-like calendar
+location America/New_York
+weekend sat sun
+hours 09:30 16:00
+holiday 01-01
+holiday 3rd mon jan
+holiday easter-2
+holiday last monday of may
+holiday 07-04
+holiday 4 thu nov
+holiday 12-25
+`
+
+const calendarRU = `{
+	"location": "Europe/Moscow",
+	"weekend": ["saturday", "sunday"],
+	"hours": ["09:00", "18:00"],
+	"holidays": ["01-01", "05-01", "05-09", "11-04", "2024-12-30", "2024-12-31"],
+	"workdays": ["2024-11-02", "2024-12-28"]
+}`
+
+func TestHoliday(t *testing.T) {
+	stages := []struct {
+		rule string
+		year int
+		m    time.Month
+		d    int
+		ok   bool
+	}{
+		{"01-01", 2024, time.January, 1, true},
+		{"02-29", 2023, 0, 0, false},
+		{"02-29", 2024, time.February, 29, true},
+		{"2024-12-31", 2024, time.December, 31, true},
+		{"2024-12-31", 2025, 0, 0, false},
+		{"last monday of may", 2024, time.May, 27, true},
+		{"last fri feb", 2024, time.February, 23, true},
+		{"1st mon sep", 2024, time.September, 2, true},
+		{"third wednesday of january", 2024, time.January, 17, true},
+		{"4 thu nov", 2024, time.November, 28, true},
+		{"5 mon feb", 2024, 0, 0, false},
+		{"easter", 2024, time.March, 31, true},
+		{"easter-2", 2024, time.March, 29, true},
+		{"easter+1", 2025, time.April, 21, true},
+		{"orthodox-easter", 2024, time.May, 5, true},
+		{"orthodox-easter", 2025, time.April, 20, true},
+	}
+	for _, st := range stages {
+		t.Run(st.rule, func(t *testing.T) {
+			h, err := ParseHoliday(st.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, d, ok := h.Date(st.year)
+			if ok != st.ok || m != st.m || d != st.d {
+				t.Errorf("date mismatch: need %d-%d %v, got %d-%d %v", st.m, st.d, st.ok, m, d, ok)
+			}
+		})
+	}
+	for _, rule := range []string{"", "13-01", "first mon", "0 mon may", "easter+x", "mon may 6", "1 monkey may", "1 mon mayday"} {
+		if _, err := ParseHoliday(rule); err != ErrBadHoliday {
+			t.Errorf("rule %q: need error %v, got %v", rule, ErrBadHoliday, err)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	us, err := ParseCalendar([]byte(calendarUS))
+	if err != nil {
+		t.Skip(err)
+	}
+	loc := us.Location()
+	d := func(m time.Month, d, hh, mm int) time.Time {
+		return time.Date(2024, m, d, hh, mm, 0, 0, loc)
+	}
+	t.Run("business day", func(t *testing.T) {
+		if !us.IsBusinessDay(d(5, 24, 12, 0)) || us.IsBusinessDay(d(5, 25, 12, 0)) || us.IsBusinessDay(d(5, 27, 12, 0)) {
+			t.Error("business day mismatch")
+		}
+		if !us.IsHoliday(d(3, 29, 0, 0)) || us.IsHoliday(d(3, 28, 0, 0)) {
+			t.Error("holiday mismatch")
+		}
+	})
+	t.Run("add", func(t *testing.T) {
+		if r := us.AddBusinessDays(d(5, 24, 10, 0), 1); !r.Equal(d(5, 28, 10, 0)) {
+			t.Errorf("add mismatch: %s", r)
+		}
+		if r := us.AddBusinessDays(d(5, 28, 10, 0), -2); !r.Equal(d(5, 23, 10, 0)) {
+			t.Errorf("add mismatch: %s", r)
+		}
+		if r := us.AddBusinessDays(d(3, 8, 2, 30), 1); !r.Equal(d(3, 11, 2, 30)) {
+			t.Errorf("add over DST mismatch: %s", r)
+		}
+	})
+	t.Run("no business days", func(t *testing.T) {
+		c := NewCalendar(time.UTC).SetWeekend(time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday,
+			time.Friday, time.Saturday)
+		if r := c.AddBusinessDays(d(5, 24, 10, 0), 1); !r.IsZero() {
+			t.Errorf("add must fail: %s", r)
+		}
+		if r := c.NextBusinessTime(d(5, 24, 10, 0)); !r.IsZero() {
+			t.Errorf("next business time must fail: %s", r)
+		}
+		if r := c.ProcessingDay(d(5, 24, 10, 0), 18*time.Hour); !r.IsZero() {
+			t.Errorf("processing day must fail: %s", r)
+		}
+	})
+	t.Run("between", func(t *testing.T) {
+		if n := us.BusinessDaysBetween(d(5, 20, 0, 0), d(6, 3, 0, 0)); n != 9 {
+			t.Errorf("between mismatch: need %d, got %d", 9, n)
+		}
+		if n := us.BusinessDaysBetween(d(6, 3, 0, 0), d(5, 20, 0, 0)); n != -9 {
+			t.Errorf("between mismatch: need %d, got %d", -9, n)
+		}
+	})
+	t.Run("hours", func(t *testing.T) {
+		if !us.IsBusinessTime(d(5, 24, 9, 30)) || us.IsBusinessTime(d(5, 24, 16, 0)) {
+			t.Error("business time mismatch")
+		}
+		stages := []struct{ t, exp time.Time }{
+			{d(5, 24, 12, 0), d(5, 24, 12, 0)},
+			{d(5, 24, 8, 0), d(5, 24, 9, 30)},
+			{d(5, 24, 19, 0), d(5, 28, 9, 30)},
+			{d(5, 26, 12, 0), d(5, 28, 9, 30)},
+		}
+		for _, st := range stages {
+			if r := us.NextBusinessTime(st.t); !r.Equal(st.exp) {
+				t.Errorf("next business time of %s mismatch: need %s, got %s", st.t, st.exp, r)
+			}
+		}
+	})
+	t.Run("cutoff", func(t *testing.T) {
+		if r := us.ProcessingDay(d(5, 23, 17, 59), 18*time.Hour); !r.Equal(d(5, 23, 0, 0)) {
+			t.Errorf("processing day mismatch: %s", r)
+		}
+		if r := us.ProcessingDay(d(5, 24, 18, 0), 18*time.Hour); !r.Equal(d(5, 28, 0, 0)) {
+			t.Errorf("processing day mismatch: %s", r)
+		}
+	})
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ru.json")
+		if err := os.WriteFile(path, []byte(calendarRU), 0644); err != nil {
+			t.Fatal(err)
+		}
+		ru, err := LoadCalendar(path)
+		if err != nil {
+			t.Skip(err)
+		}
+		loc := ru.Location()
+		if !ru.IsBusinessDay(time.Date(2024, 11, 2, 12, 0, 0, 0, loc)) {
+			t.Error("transferred saturday must be a business day")
+		}
+		if ru.IsBusinessDay(time.Date(2024, 11, 4, 12, 0, 0, 0, loc)) {
+			t.Error("holiday must not be a business day")
+		}
+		if n := ru.BusinessDaysBetween(time.Date(2024, 12, 28, 0, 0, 0, 0, loc), time.Date(2025, 1, 2, 0, 0, 0, 0, loc)); n != 1 {
+			t.Errorf("between mismatch: need %d, got %d", 1, n)
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, def := range []string{"foo bar", "weekend xx", "hours 18:00 09:00", "holiday easter+y", `{"weekend": 1}`} {
+			if _, err := ParseCalendar([]byte(def)); err == nil {
+				t.Errorf("definition %q must fail", def)
+			}
+		}
+	})
+}
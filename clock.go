@@ -2,6 +2,7 @@ package clock
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -11,6 +12,21 @@ const (
 	StatusActive
 )
 
+// BackwardPolicy describes how clock handles backward steps of wall clock (including negative Jump()).
+type BackwardPolicy uint8
+
+const (
+	// BackwardAllow applies backward steps immediately, Now() may decrease.
+	BackwardAllow BackwardPolicy = iota
+	// BackwardFreeze freezes Now() until real time catches up.
+	BackwardFreeze
+	// BackwardSlew slows down Now() (see Clock.BackwardSlewRate) until real time catches up.
+	BackwardSlew
+)
+
+// defaultSlewRate is a speed of clock during backward slewing.
+const defaultSlewRate = .5
+
 // Interface represents clock interface.
 type Interface interface {
 	Now() time.Time
@@ -18,17 +34,79 @@ type Interface interface {
 
 // Clock is a fast replacement of base methods of time package.
 type Clock struct {
-	// Clock precision.
+	// Atomic fields go first to keep 64-bit alignment on 32-bit platforms.
+	// Unix time in nanoseconds.
+	ns,
+	delta int64
+	// Monotonic reading, see Mono().
+	mono int64
+	// Count of detected backward steps.
+	backward uint64
+	// Counters of reads of backed off clock and ticks.
+	reads, ticks uint64
+	// The last value returned by NowUnique() and clock time it was issued at.
+	uniq, uniqNs int64
+	// Tick jitter accumulators, see Stats().
+	jitterSum, jitterN, jitterMax int64
+	// Copy of Precision for concurrent readers, set on start and by SetPrecision.
+	prec int64
+
+	// Clock precision. Use SetPrecision to change it on running clock.
 	// Settings this param too small (less than microseconds) or too big (great than second) is counterproductive.
 	Precision time.Duration
+	// Backward steps handling policy. BackwardFreeze and BackwardSlew guarantee that Now() never decreases.
+	Backward BackwardPolicy
+	// Speed of clock during backward slewing relative to real time, (0, 1). Default is 0.5.
+	BackwardSlewRate float64
+	// OnBackward is called on each detected backward step with step size.
+	OnBackward func(step time.Duration)
+	// Adaptive enables adaptive ticking: ticker backs off when Now() isn't called and parks if there are no
+	// scheduled jobs. Backed off clock refreshes time inline on read, so Now() accuracy stays within Precision, but
+	// scheduled jobs run with lower precision until the next read.
+	Adaptive bool
+	// Period without reads after which ticker backs off. Default is 100*Precision.
+	AdaptiveIdle time.Duration
+	// The slowest tick period of backed off ticker with scheduled jobs. Default is 1 second.
+	AdaptiveMax time.Duration
+	// Scheduler metrics, see ExpvarMetrics.
+	Metrics SchedMetrics
+	// Hooks called around each run of scheduled job in scheduler goroutine.
+	BeforeRun func(info JobInfo)
+	AfterRun  func(info JobInfo, dur time.Duration, err error)
+	// OnPanic is called on panic of scheduled job, the job continues to run by schedule. By default panic is
+	// propagated.
+	OnPanic func(v any)
+	// Storage of named jobs state, see JobOptions.Name and FileStore.
+	Store JobStore
+	// OnStoreError is called on failed load or save of job state.
+	OnStoreError func(name string, err error)
 
 	status int32
-	sec, nsec,
-	delta int64
+	// State of backed off ticker, see Adaptive: 0 - ticking, 1 - backed off, 2 - woken up by read.
+	lazy int32
+
+	// Tick state protected by tmux.
+	tmux   sync.Mutex
+	target int64
+	tmono  int64
+	// Slew state: total offset, applied part, start (monotonic) and duration.
+	slewTotal, slewDone,
+	slewStart, slewOver int64
+	// Dilation state: rate and anchor (clock time and monotonic reading of the last rate change).
+	scaled     bool
+	rate       float64
+	anchorTime int64
+	anchorMono int64
 
 	sched *sched
 
+	// Lifecycle state protected by lmux: run generation, ticker goroutine controls.
+	lmux   sync.Mutex
+	gen    uint64
 	cancel context.CancelFunc
+	reset  chan time.Duration
+	wake   chan struct{}
+	done   chan struct{}
 }
 
 // NewClock makes new clock with default precision (1 millisecond).
@@ -38,40 +116,216 @@ func NewClock() *Clock {
 
 // NewClockWP makes new clock with given precision.
 func NewClockWP(precision time.Duration) *Clock {
-	c := &Clock{Precision: precision}
+	c := &Clock{Precision: precision, prec: int64(precision)}
 	return c
 }
 
 // Start initializes and starts the clock.
 func (c *Clock) Start() {
+	c.StartContext(context.Background())
+}
+
+// StartContext starts the clock bound to ctx. Clock stops when ctx is done.
+func (c *Clock) StartContext(ctx context.Context) {
+	c.lmux.Lock()
+	defer c.lmux.Unlock()
 	if c.Active() {
 		return
 	}
-	atomic.StoreInt32(&c.status, StatusActive)
 	if c.Precision == 0 {
 		c.Precision = time.Millisecond
 	}
+	atomic.StoreInt64(&c.prec, int64(c.Precision))
+	atomic.StoreInt32(&c.status, StatusActive)
+	// Only refresh time under lock: overdue jobs run in ticker goroutine, since they may schedule new jobs.
+	c.refresh()
+	c.gen++
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.getSched().setContext(ctx)
+	c.done = make(chan struct{})
+	c.reset = make(chan time.Duration, 1)
+	c.wake = make(chan struct{}, 1)
+	go c.run(ctx, c.gen, c.Precision, c.reset, c.wake, c.done)
+}
+
+func (c *Clock) run(ctx context.Context, gen uint64, precision time.Duration, reset chan time.Duration,
+	wake, done chan struct{}) {
+	t := time.NewTicker(precision)
+	var (
+		// Current tick period, reads counter seen on previous tick and period without reads.
+		period = precision
+		reads  = c.Reads()
+		idle   time.Duration
+		// Monotonic reading of previous tick, zero after ticker reset.
+		last int64
+	)
+	// fast restores normal ticking after back off.
+	fast := func() {
+		if period != precision || atomic.LoadInt32(&c.lazy) != 0 {
+			period, last = precision, 0
+			t.Reset(period)
+			c.tick()
+			atomic.StoreInt32(&c.lazy, 0)
+		}
+		reads, idle = c.Reads(), 0
+	}
+	defer func() {
+		t.Stop()
+		atomic.StoreInt32(&c.lazy, 0)
+		c.lmux.Lock()
+		// Parent context is done, mark clock stopped unless it was already restarted.
+		if c.gen == gen {
+			atomic.StoreInt32(&c.status, StatusIdle)
+			c.cancel()
+			c.cancel = nil
+			c.getSched().setContext(nil)
+		}
+		c.lmux.Unlock()
+		close(done)
+	}()
+	// Run jobs which became overdue while clock was stopped.
 	c.tick()
-	var ctx context.Context
-	ctx, c.cancel = context.WithCancel(context.Background())
-	go func(ctx context.Context) {
-		t := time.NewTicker(c.Precision)
-		for {
-			select {
-			case <-t.C:
-				c.tick()
-			case <-ctx.Done():
+	for {
+		select {
+		case <-t.C:
+			now := monoNow()
+			if last != 0 {
+				c.observeTick(time.Duration(now-last), period)
+			}
+			last = now
+			c.tick()
+			if !c.Adaptive {
+				continue
+			}
+			if r := c.Reads(); r != reads {
+				fast()
+				continue
+			}
+			if idle += period; idle < c.adaptiveIdle(precision) {
+				continue
+			}
+			atomic.StoreInt32(&c.lazy, 1)
+			if !c.getSched().pending() {
+				// Park until read, new job or retune.
 				t.Stop()
-				return
+				select {
+				case <-wake:
+				case precision = <-reset:
+				case <-ctx.Done():
+					return
+				}
+				period = 0
+				fast()
+				continue
 			}
+			if max := c.adaptiveMax(); period < max {
+				if period *= 2; period > max {
+					period = max
+				}
+				t.Reset(period)
+				last = 0
+			}
+		case precision = <-reset:
+			period = 0
+			fast()
+		case <-wake:
+			fast()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Clock) adaptiveIdle(precision time.Duration) time.Duration {
+	if c.AdaptiveIdle > 0 {
+		return c.AdaptiveIdle
+	}
+	return 100 * precision
+}
+
+func (c *Clock) adaptiveMax() time.Duration {
+	if c.AdaptiveMax > 0 {
+		return c.AdaptiveMax
+	}
+	return time.Second
+}
+
+// wakeup resumes normal ticking of backed off clock.
+func (c *Clock) wakeup() {
+	c.lmux.Lock()
+	defer c.lmux.Unlock()
+	if c.wake != nil {
+		select {
+		case c.wake <- struct{}{}:
+		default:
 		}
-	}(ctx)
+	}
 }
 
+// Stop stops the clock and waits until ticker goroutine and in-flight scheduled jobs finish.
+// Don't call it from scheduled job, use Shutdown with timeout or call it in separate goroutine.
 func (c *Clock) Stop() {
-	if atomic.LoadInt32(&c.status) == StatusActive {
+	_ = c.Shutdown(context.Background())
+}
+
+// Shutdown stops the clock and waits until ticker goroutine, in-flight scheduled jobs and pending saves of jobs state
+// (see Store) finish or ctx is done.
+// Returns ctx error if waiting was interrupted; the clock is stopped anyway.
+func (c *Clock) Shutdown(ctx context.Context) error {
+	c.lmux.Lock()
+	done := c.done
+	if c.cancel != nil {
 		c.cancel()
-		atomic.StoreInt32(&c.status, StatusIdle)
+		c.cancel = nil
+		c.getSched().setContext(nil)
+	}
+	// Prevent stopping goroutine from touching state of the next run.
+	c.gen++
+	atomic.StoreInt32(&c.status, StatusIdle)
+	c.lmux.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.tmux.Lock()
+	s := c.sched
+	c.tmux.Unlock()
+	if s == nil {
+		return nil
+	}
+	idle := make(chan struct{})
+	go func() {
+		s.wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetPrecision changes clock precision. Running clock retunes its ticker.
+func (c *Clock) SetPrecision(precision time.Duration) {
+	if precision <= 0 {
+		return
+	}
+	c.lmux.Lock()
+	defer c.lmux.Unlock()
+	c.Precision = precision
+	atomic.StoreInt64(&c.prec, int64(precision))
+	if c.Active() {
+		// Replace pending value, if any: the latest one wins.
+		select {
+		case <-c.reset:
+		default:
+		}
+		c.reset <- precision
 	}
 }
 
@@ -81,7 +335,84 @@ func (c *Clock) Active() bool {
 
 // Now returns current time.
 func (c *Clock) Now() time.Time {
-	return time.Unix(atomic.LoadInt64(&c.sec), atomic.LoadInt64(&c.nsec))
+	ns := atomic.LoadInt64(&c.ns)
+	if atomic.LoadInt32(&c.lazy) != 0 {
+		ns = c.read()
+	}
+	return time.Unix(0, ns)
+}
+
+// NowUnique returns current time like Now(), but each call returns strictly greater value: calls within one tick get
+// time of the tick plus sequence number in nanoseconds. Sequence never runs ahead of the next tick: if it's exhausted
+// (more than Precision/1ns calls per tick), the call refreshes the clock. Only clock that doesn't advance (paused by
+// SetRate(0) or frozen by BackwardFreeze) lets sequence run ahead of it.
+//
+// Values are strictly increasing until Now() decreases: after backward step (see BackwardPolicy) sequence restarts
+// from the new time.
+func (c *Clock) NowUnique() time.Time {
+	for {
+		last, base := atomic.LoadInt64(&c.uniq), atomic.LoadInt64(&c.uniqNs)
+		ns := c.Now().UnixNano()
+		prec := atomic.LoadInt64(&c.prec)
+		if prec <= 0 {
+			prec = int64(time.Millisecond)
+		}
+		next := last + 1
+		switch {
+		case ns < base:
+			// Clock stepped back.
+			next = ns
+		case next < ns:
+			next = ns
+		case next >= ns+prec:
+			// Sequence exhausted, start the next tick.
+			c.refresh()
+			if atomic.LoadInt64(&c.ns) != ns {
+				continue
+			}
+		}
+		if atomic.CompareAndSwapInt64(&c.uniq, last, next) {
+			atomic.StoreInt64(&c.uniqNs, ns)
+			return time.Unix(0, next)
+		}
+	}
+}
+
+// read refreshes time of backed off clock, wakes up its ticker and returns fresh time.
+func (c *Clock) read() int64 {
+	atomic.AddUint64(&c.reads, 1)
+	c.refresh()
+	// The first read wakes up ticker, the rest only refresh time until it resumes.
+	if atomic.CompareAndSwapInt32(&c.lazy, 1, 2) {
+		c.wakeup()
+	}
+	return atomic.LoadInt64(&c.ns)
+}
+
+// Reads returns count of reads of backed off clock, see Adaptive.
+func (c *Clock) Reads() uint64 {
+	return atomic.LoadUint64(&c.reads)
+}
+
+// Ticks returns count of clock updates.
+func (c *Clock) Ticks() uint64 {
+	return atomic.LoadUint64(&c.ticks)
+}
+
+// BackwardSteps returns count of detected backward steps of wall clock.
+func (c *Clock) BackwardSteps() uint64 {
+	return atomic.LoadUint64(&c.backward)
+}
+
+// Mono returns monotonic reading (nanoseconds since process-wide origin) with clock precision.
+// Unlike Now(), readings never decrease: they ignore wall clock steps and Jump() deltas.
+func (c *Clock) Mono() int64 {
+	return atomic.LoadInt64(&c.mono)
+}
+
+// Since returns time elapsed since monotonic mark taken by Mono().
+func (c *Clock) Since(mark int64) time.Duration {
+	return time.Duration(c.Mono() - mark)
 }
 
 // Jump performs time travel.
@@ -90,25 +421,194 @@ func (c *Clock) Jump(delta time.Duration) {
 	c.tick()
 }
 
+// Slew gradually applies delta over given duration by adjusting clock rate, like adjtime(2).
+// New slew replaces the previous one; unapplied rest of the previous slew is added to delta.
+func (c *Clock) Slew(delta, over time.Duration) {
+	if over <= 0 {
+		c.Jump(delta)
+		return
+	}
+	c.tmux.Lock()
+	c.slewTotal = c.slewTotal - c.slewDone + int64(delta)
+	c.slewDone, c.slewStart, c.slewOver = 0, monoNow(), int64(over)
+	c.tmux.Unlock()
+	c.tick()
+}
+
+// Slewing returns rest of offset to apply by current slew.
+func (c *Clock) Slewing() time.Duration {
+	c.tmux.Lock()
+	defer c.tmux.Unlock()
+	return time.Duration(c.slewTotal - c.slewDone)
+}
+
+// Set sets clock to given time. Current slew is cancelled.
+//
+// Scheduled jobs see the step like a step of wall clock or Jump: after backward step their next runs are shifted by
+// step size, so they aren't postponed (see JobOptions.KeepOnBackward); after forward step overdue jobs run according
+// to their misfire policy.
+func (c *Clock) Set(t time.Time) {
+	c.tmux.Lock()
+	c.slewTotal, c.slewDone, c.slewOver = 0, 0, 0
+	step := t.UnixNano() - c.base(monoNow()) - atomic.LoadInt64(&c.delta)
+	atomic.AddInt64(&c.delta, step)
+	// Step of clock that never ticked can't be detected by tick.
+	ticked := c.target != 0
+	c.tmux.Unlock()
+	if step < 0 && !ticked && c.Backward == BackwardAllow {
+		c.getSched().shift(time.Duration(step))
+	}
+	c.tick()
+}
+
+// SetRate sets clock speed relative to real time, e.g. 10 for simulation running ten times faster or 0.5 for slow
+// motion. Zero rate pauses the clock, negative rates are ignored.
+//
+// Rate change keeps time continuous: clock continues from its current value at new speed. Jump, Set and Slew work on
+// top of dilated time. Scheduled jobs run in clock (dilated) time.
+func (c *Clock) SetRate(rate float64) {
+	if rate < 0 {
+		return
+	}
+	c.tmux.Lock()
+	mono := monoNow()
+	c.anchorTime, c.anchorMono = c.base(mono), mono
+	c.rate, c.scaled = rate, true
+	c.tmux.Unlock()
+	c.tick()
+}
+
+// Rate returns clock speed relative to real time.
+func (c *Clock) Rate() float64 {
+	c.tmux.Lock()
+	defer c.tmux.Unlock()
+	if !c.scaled {
+		return 1
+	}
+	return c.rate
+}
+
 func (c *Clock) Relative(raw string) time.Time {
-	if dur, err := Relative(raw); err == nil {
+	return c.RelativeWith(DefaultRelativeParser, raw)
+}
+
+// RelativeWith returns current time shifted by raw span using given parser.
+func (c *Clock) RelativeWith(p *RelativeParser, raw string) time.Time {
+	if dur, err := p.Parse(raw); err == nil {
 		return c.Now().Add(dur)
 	}
 	return time.Time{}
 }
 
 func (c *Clock) Schedule(dur time.Duration, fn func()) {
+	c.getSched().register(dur, fn, c.clockNow())
+	c.wakeup()
+}
+
+// Every registers fn to call every dur like Schedule and returns handle to unregister it by Stop.
+func (c *Clock) Every(dur time.Duration, fn func()) Timer {
+	h := c.getSched().registerEvery(dur, fn, c.clockNow())
+	c.wakeup()
+	return h
+}
+
+// ScheduleJob registers job to call every dur with given policy: initial delay, jitter and retries of failed runs.
+// Job context is cancelled when clock stops.
+func (c *Clock) ScheduleJob(dur time.Duration, job Job, opts JobOptions) {
+	c.getSched().registerJob(dur, job, opts, c.clockNow())
+	c.wakeup()
+}
+
+// ScheduleRule registers fn to call on each occurrence of recurrence rule.
+// Past occurrences are skipped.
+func (c *Clock) ScheduleRule(rule *RRule, fn func()) {
+	c.getSched().registerRule(rule.Iter(), fn, c.clockNow())
+	c.wakeup()
+}
+
+func (c *Clock) getSched() *sched {
+	c.tmux.Lock()
+	defer c.tmux.Unlock()
 	if c.sched == nil {
-		c.sched = &sched{}
+		c.sched = &sched{clock: c}
 	}
-	c.sched.register(dur, fn, time.Now())
+	return c.sched
+}
+
+// clockNow returns current clock time even if clock never ticked.
+func (c *Clock) clockNow() time.Time {
+	if ns := atomic.LoadInt64(&c.ns); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	c.tmux.Lock()
+	defer c.tmux.Unlock()
+	return time.Unix(0, c.base(monoNow())+atomic.LoadInt64(&c.delta))
+}
+
+// base returns clock time without delta considering dilation. Must be called under tmux.
+func (c *Clock) base(mono int64) int64 {
+	if !c.scaled {
+		return time.Now().UnixNano()
+	}
+	return c.anchorTime + int64(float64(mono-c.anchorMono)*c.rate)
 }
 
 func (c *Clock) tick() {
-	ts := time.Now().UnixNano() + atomic.LoadInt64(&c.delta)
-	atomic.StoreInt64(&c.sec, ts/1e9)
-	atomic.StoreInt64(&c.nsec, ts%1e9)
-	if c.sched != nil {
-		c.sched.apply(c.Now())
+	if sched := c.refresh(); sched != nil {
+		sched.apply(time.Unix(0, atomic.LoadInt64(&c.ns)))
+	}
+}
+
+// refresh updates clock time and returns scheduler to apply.
+func (c *Clock) refresh() *sched {
+	c.tmux.Lock()
+	atomic.AddUint64(&c.ticks, 1)
+	mono := monoNow()
+	if c.slewOver > 0 {
+		elapsed, want := mono-c.slewStart, c.slewTotal
+		if elapsed < c.slewOver {
+			want = int64(float64(c.slewTotal) * float64(elapsed) / float64(c.slewOver))
+		} else {
+			c.slewTotal, c.slewOver = 0, 0
+		}
+		atomic.AddInt64(&c.delta, want-c.slewDone)
+		c.slewDone = want
+		if c.slewOver == 0 {
+			c.slewDone = 0
+		}
+	}
+	ts := c.base(mono) + atomic.LoadInt64(&c.delta)
+	var step int64
+	if c.target != 0 && ts < c.target {
+		step = c.target - ts
+		atomic.AddUint64(&c.backward, 1)
+	}
+	prev := atomic.LoadInt64(&c.ns)
+	switch {
+	case ts >= prev || c.Backward == BackwardAllow:
+		atomic.StoreInt64(&c.ns, ts)
+	case c.Backward == BackwardFreeze:
+		// Keep previous value.
+	case c.Backward == BackwardSlew:
+		rate := c.BackwardSlewRate
+		if rate <= 0 || rate >= 1 {
+			rate = defaultSlewRate
+		}
+		atomic.StoreInt64(&c.ns, prev+int64(float64(mono-c.tmono)*rate))
+	}
+	c.target, c.tmono = ts, mono
+	if mono > atomic.LoadInt64(&c.mono) {
+		atomic.StoreInt64(&c.mono, mono)
+	}
+	sched := c.sched
+	c.tmux.Unlock()
+
+	if step > 0 && c.Backward == BackwardAllow && sched != nil {
+		// Now() decreased, keep intervals of jobs.
+		sched.shift(-time.Duration(step))
+	}
+	if step > 0 && c.OnBackward != nil {
+		c.OnBackward(time.Duration(step))
 	}
+	return sched
 }
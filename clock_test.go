@@ -1,6 +1,9 @@
 package clock
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -61,7 +64,7 @@ func TestRelative(t *testing.T) {
 		{"-1y 12month", "-17532h43m12s"},
 		{"-55s500ms", "-55.5s"},
 		{"-300ms20s 5day", "-120h0m20.3s"},
-		{"2century 43 y 3M 3 w 15d  17 h 43m  34 s 400ms 123 us  55 ns", "2132850h41m22.400000055s"},
+		{"2century 43 y 3M 3 w 15d  17 h 43m  34 s 400ms 123 us  55 ns", "2133211h24m22.400123055s"},
 	}
 	for _, span := range spans {
 		t.Run(span.key, func(t *testing.T) {
@@ -86,6 +89,26 @@ func BenchmarkClock(b *testing.B) {
 		_ = n
 		c.Stop()
 	})
+	b.Run("clock.Mono()", func(b *testing.B) {
+		c := NewClock()
+		c.Start()
+		var n int64
+		for i := 0; i < b.N; i++ {
+			n = c.Mono()
+		}
+		_ = n
+		c.Stop()
+	})
+	b.Run("clock.NowUnique()", func(b *testing.B) {
+		c := NewClock()
+		c.Start()
+		var n time.Time
+		for i := 0; i < b.N; i++ {
+			n = c.NowUnique()
+		}
+		_ = n
+		c.Stop()
+	})
 	b.Run("time.Now()", func(b *testing.B) {
 		var n time.Time
 		for i := 0; i < b.N; i++ {
@@ -107,3 +130,504 @@ func BenchmarkRelative(b *testing.B) {
 	}
 	c.Stop()
 }
+
+// manualMono is a monotonic source advanced by test.
+type manualMono int64
+
+func (m *manualMono) Mono() int64 { return atomic.LoadInt64((*int64)(m)) }
+
+func (m *manualMono) add(d time.Duration) { atomic.AddInt64((*int64)(m), int64(d)) }
+
+func TestMono(t *testing.T) {
+	t.Run("jump", func(t *testing.T) {
+		// Clock isn't started: Jump refreshes it synchronously, so readings don't depend on ticker scheduling.
+		c := NewClock()
+		c.SetRate(0)
+		mark, now := c.Mono(), c.Now()
+		c.Jump(-time.Hour)
+		if c.Now().After(now) {
+			t.Error("wall clock must go backward")
+		}
+		if c.Mono() < mark || c.Since(mark) < 0 {
+			t.Errorf("monotonic reading decreased: %d < %d", c.Mono(), mark)
+		}
+		c.Jump(2 * time.Hour)
+		if d := c.Since(mark); d >= time.Hour {
+			t.Errorf("monotonic reading must ignore jumps: %s", d)
+		}
+		time.Sleep(10 * time.Millisecond)
+		c.Jump(0)
+		if d := c.Since(mark); d < 10*time.Millisecond {
+			t.Errorf("elapsed mismatch: %s", d)
+		}
+	})
+	t.Run("stopwatch", func(t *testing.T) {
+		var m manualMono
+		sw := NewStopwatch(&m)
+		m.add(10 * time.Millisecond)
+		if d := sw.Lap(); d != 10*time.Millisecond {
+			t.Errorf("lap mismatch: %s", d)
+		}
+		m.add(time.Millisecond)
+		if d := sw.Lap(); d != time.Millisecond {
+			t.Errorf("lap mismatch: %s", d)
+		}
+		if d := sw.Elapsed(); d != 11*time.Millisecond {
+			t.Errorf("elapsed mismatch: %s", d)
+		}
+		sw.Reset()
+		if d := sw.Elapsed(); d != 0 {
+			t.Errorf("elapsed after reset mismatch: %s", d)
+		}
+	})
+}
+
+func TestBackward(t *testing.T) {
+	sample := func(t *testing.T, c *Clock, dur time.Duration) {
+		prev := c.Now()
+		for deadline := time.Now().Add(dur); time.Now().Before(deadline); {
+			now := c.Now()
+			if now.Before(prev) {
+				t.Fatalf("clock went backward: %s < %s", now, prev)
+			}
+			prev = now
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+	t.Run("allow", func(t *testing.T) {
+		var step time.Duration
+		c := NewClock()
+		c.OnBackward = func(s time.Duration) { step = s }
+		c.Start()
+		defer c.Stop()
+		n0 := c.Now()
+		c.Jump(-time.Minute)
+		if !c.Now().Before(n0) {
+			t.Error("clock must go backward")
+		}
+		if c.BackwardSteps() != 1 || step < time.Minute-10*time.Millisecond || step > time.Minute {
+			t.Errorf("backward step mismatch: count %d, step %s", c.BackwardSteps(), step)
+		}
+	})
+	t.Run("freeze", func(t *testing.T) {
+		var step int64
+		c := NewClock()
+		c.Backward = BackwardFreeze
+		c.OnBackward = func(s time.Duration) { atomic.StoreInt64(&step, int64(s)) }
+		c.Start()
+		defer c.Stop()
+		n0 := c.Now()
+		c.Jump(-50 * time.Millisecond)
+		sample(t, c, 20*time.Millisecond)
+		if n1 := c.Now(); !n1.Equal(n0) {
+			t.Errorf("clock must be frozen: %s != %s", n1, n0)
+		}
+		sample(t, c, 50*time.Millisecond)
+		if !c.Now().After(n0) {
+			t.Error("clock must catch up")
+		}
+		if s := time.Duration(atomic.LoadInt64(&step)); c.BackwardSteps() != 1 || s < 45*time.Millisecond {
+			t.Errorf("backward step mismatch: count %d, step %s", c.BackwardSteps(), s)
+		}
+	})
+	t.Run("slew", func(t *testing.T) {
+		c := NewClock()
+		c.Backward = BackwardSlew
+		c.BackwardSlewRate = .5
+		c.Start()
+		defer c.Stop()
+		n0 := c.Now()
+		c.Jump(-20 * time.Millisecond)
+		sample(t, c, 20*time.Millisecond)
+		if d := c.Now().Sub(n0); d <= 0 || d > 15*time.Millisecond {
+			t.Errorf("clock must run slower: %s", d)
+		}
+		sample(t, c, 60*time.Millisecond)
+		real := time.Now().Add(-20 * time.Millisecond)
+		if d := real.Sub(c.Now()); d > 5*time.Millisecond || d < -5*time.Millisecond {
+			t.Errorf("clock must catch up real time: diff %s", d)
+		}
+	})
+}
+
+func TestSlew(t *testing.T) {
+	t.Run("slew", func(t *testing.T) {
+		// Paused clock isn't started: offset is exactly applied part of slew and Jump refreshes it synchronously.
+		c := NewClock()
+		c.SetRate(0)
+		n0 := c.Now()
+		c.Slew(100*time.Millisecond, 100*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		c.Jump(0)
+		d, r := c.Now().Sub(n0), c.Slewing()
+		if d < 50*time.Millisecond || d > 100*time.Millisecond || d+r != 100*time.Millisecond {
+			t.Errorf("slew progress mismatch: applied %s, rest %s", d, r)
+		}
+		time.Sleep(50 * time.Millisecond)
+		c.Jump(0)
+		if d := c.Now().Sub(n0); d != 100*time.Millisecond {
+			t.Errorf("offset mismatch: %s", d)
+		}
+		if r := c.Slewing(); r != 0 {
+			t.Errorf("slew must be finished: %s", r)
+		}
+		if c.BackwardSteps() != 0 {
+			t.Error("slew must not produce backward steps")
+		}
+	})
+	t.Run("set", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		c.Slew(time.Hour, time.Hour)
+		x := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		c.Set(x)
+		if n := c.Now(); !n.Equal(x) {
+			t.Errorf("set mismatch: %s", n)
+		}
+		if r := c.Slewing(); r != 0 {
+			t.Errorf("set must cancel slew: %s", r)
+		}
+	})
+}
+
+func TestRate(t *testing.T) {
+	t.Run("dilation", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		n0 := c.Now()
+		c.SetRate(10)
+		if d := c.Now().Sub(n0); d < 0 || d > 2*time.Millisecond {
+			t.Errorf("rate change must keep time continuous: %s", d)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if d := c.Now().Sub(n0); d < 150*time.Millisecond || d > 300*time.Millisecond {
+			t.Errorf("dilated time mismatch: %s", d)
+		}
+		c.SetRate(.5)
+		n1 := c.Now()
+		if r := c.Rate(); r != .5 {
+			t.Errorf("rate mismatch: %f", r)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if d := c.Now().Sub(n1); d < 5*time.Millisecond || d > 15*time.Millisecond {
+			t.Errorf("dilated time mismatch: %s", d)
+		}
+	})
+	t.Run("pause", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		c.SetRate(0)
+		n0 := c.Now()
+		time.Sleep(5 * time.Millisecond)
+		if n1 := c.Now(); !n1.Equal(n0) {
+			t.Errorf("clock must be paused: %s != %s", n1, n0)
+		}
+		c.Jump(time.Hour)
+		if d := c.Now().Sub(n0); d != time.Hour {
+			t.Errorf("jump over paused clock mismatch: %s", d)
+		}
+	})
+	t.Run("schedule", func(t *testing.T) {
+		var a uint32
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		c.SetRate(10)
+		c.Schedule(50*time.Millisecond, func() { atomic.AddUint32(&a, 1) })
+		time.Sleep(30 * time.Millisecond)
+		if v := atomic.LoadUint32(&a); v < 3 || v > 6 {
+			t.Errorf("job must run in dilated time: %d runs", v)
+		}
+	})
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Run("context", func(t *testing.T) {
+		c := NewClock()
+		ctx, cancel := context.WithCancel(context.Background())
+		c.StartContext(ctx)
+		if !c.Active() {
+			t.Fatal("clock must be active")
+		}
+		cancel()
+		for i := 0; i < 100 && c.Active(); i++ {
+			time.Sleep(time.Millisecond)
+		}
+		if c.Active() {
+			t.Fatal("clock must stop on context cancel")
+		}
+		n := c.Now()
+		time.Sleep(5 * time.Millisecond)
+		if !c.Now().Equal(n) {
+			t.Error("stopped clock must not tick")
+		}
+	})
+	t.Run("restart", func(t *testing.T) {
+		c := NewClock()
+		for i := 0; i < 50; i++ {
+			c.Start()
+			c.Stop()
+		}
+		c.Start()
+		defer c.Stop()
+		n := c.Now()
+		time.Sleep(5 * time.Millisecond)
+		if !c.Now().After(n) {
+			t.Error("restarted clock must tick")
+		}
+	})
+	t.Run("wait", func(t *testing.T) {
+		var a uint32
+		c := NewClock()
+		c.Start()
+		c.Schedule(time.Millisecond, func() {
+			time.Sleep(20 * time.Millisecond)
+			atomic.StoreUint32(&a, 1)
+		})
+		time.Sleep(5 * time.Millisecond)
+		c.Stop()
+		if atomic.LoadUint32(&a) != 1 {
+			t.Error("stop must wait for in-flight job")
+		}
+	})
+	t.Run("shutdown", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		c.Schedule(time.Millisecond, func() { time.Sleep(50 * time.Millisecond) })
+		time.Sleep(5 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		if err := c.Shutdown(ctx); err != context.DeadlineExceeded {
+			t.Errorf("shutdown must time out, got %v", err)
+		}
+		if c.Active() {
+			t.Error("clock must be stopped")
+		}
+		if err := c.Shutdown(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("overdue", func(t *testing.T) {
+		var a uint32
+		c := NewClock()
+		c.Schedule(time.Millisecond, func() {
+			if atomic.AddUint32(&a, 1) == 1 {
+				c.AfterFunc(time.Millisecond, func() {})
+			}
+		})
+		time.Sleep(2 * time.Millisecond)
+		started := make(chan struct{})
+		go func() {
+			c.Start()
+			close(started)
+		}()
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatal("start must not block on overdue job scheduling new one")
+		}
+		c.Stop()
+		if atomic.LoadUint32(&a) == 0 {
+			t.Error("overdue job must run after start")
+		}
+	})
+	t.Run("precision", func(t *testing.T) {
+		c := NewClockWP(time.Hour)
+		c.Start()
+		defer c.Stop()
+		n := c.Now()
+		c.SetPrecision(time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		if !c.Now().After(n) {
+			t.Error("clock must tick with new precision")
+		}
+	})
+}
+
+func TestAdaptive(t *testing.T) {
+	t.Run("park", func(t *testing.T) {
+		c := NewClock()
+		c.Adaptive, c.AdaptiveIdle = true, 10*time.Millisecond
+		c.Start()
+		defer c.Stop()
+		time.Sleep(50 * time.Millisecond)
+		n := c.Ticks()
+		time.Sleep(30 * time.Millisecond)
+		if d := c.Ticks() - n; d != 0 {
+			t.Errorf("idle clock must park, %d ticks", d)
+		}
+		if d := time.Since(c.Now()); d < 0 || d > 2*time.Millisecond {
+			t.Errorf("parked clock must refresh on read: %s", d)
+		}
+		if r := c.Reads(); r != 1 {
+			t.Errorf("reads mismatch: %d", r)
+		}
+		time.Sleep(5 * time.Millisecond)
+		n = c.Ticks()
+		for i := 0; i < 5; i++ {
+			_ = c.Now()
+			time.Sleep(2 * time.Millisecond)
+		}
+		if d := c.Ticks() - n; d < 3 {
+			t.Errorf("clock must resume ticking on reads, %d ticks", d)
+		}
+	})
+	t.Run("backoff", func(t *testing.T) {
+		var a uint32
+		c := NewClock()
+		c.Adaptive, c.AdaptiveIdle, c.AdaptiveMax = true, 10*time.Millisecond, 20*time.Millisecond
+		c.Start()
+		defer c.Stop()
+		c.Schedule(5*time.Millisecond, func() { atomic.AddUint32(&a, 1) })
+		time.Sleep(50 * time.Millisecond)
+		n := c.Ticks()
+		time.Sleep(60 * time.Millisecond)
+		if d := c.Ticks() - n; d == 0 || d > 5 {
+			t.Errorf("clock with jobs must back off, %d ticks", d)
+		}
+		if atomic.LoadUint32(&a) == 0 {
+			t.Error("backed off clock must run jobs")
+		}
+	})
+}
+
+func TestNowUnique(t *testing.T) {
+	t.Run("concurrent", func(t *testing.T) {
+		const workers, calls = 8, 10000
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		var (
+			res [workers][]int64
+			wg  sync.WaitGroup
+		)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				buf := make([]int64, 0, calls)
+				for j := 0; j < calls; j++ {
+					v := c.NowUnique().UnixNano()
+					if n := c.Now().UnixNano(); v >= n+int64(c.Precision) {
+						t.Errorf("value runs ahead of the next tick: %d >= %d", v, n+int64(c.Precision))
+						return
+					}
+					buf = append(buf, v)
+				}
+				res[i] = buf
+			}(i)
+		}
+		wg.Wait()
+		seen := make(map[int64]struct{}, workers*calls)
+		for i := 0; i < workers; i++ {
+			for j, v := range res[i] {
+				if j > 0 && v <= res[i][j-1] {
+					t.Fatalf("value must increase: %d <= %d", v, res[i][j-1])
+				}
+				if _, ok := seen[v]; ok {
+					t.Fatalf("duplicate value %d", v)
+				}
+				seen[v] = struct{}{}
+			}
+		}
+	})
+	t.Run("precision", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				c.SetPrecision(time.Duration(1+i%2) * time.Millisecond)
+			}
+		}()
+		prev := c.NowUnique()
+		for i := 0; i < 10000; i++ {
+			v := c.NowUnique()
+			if !v.After(prev) {
+				t.Fatalf("value must increase: %s <= %s", v, prev)
+			}
+			prev = v
+		}
+		<-done
+	})
+	t.Run("exhaust", func(t *testing.T) {
+		c := NewClockWP(100 * time.Nanosecond)
+		c.Jump(0)
+		var prev int64
+		for i := 0; i < 1000; i++ {
+			v := c.NowUnique().UnixNano()
+			if v <= prev {
+				t.Fatalf("value must increase: %d <= %d", v, prev)
+			}
+			prev = v
+		}
+	})
+	t.Run("backward", func(t *testing.T) {
+		c := NewClock()
+		c.Jump(0)
+		_ = c.NowUnique()
+		c.Jump(-time.Hour)
+		if d := c.NowUnique().Sub(c.Now()); d < 0 || d >= c.Precision {
+			t.Errorf("sequence must restart after backward step: %s", d)
+		}
+	})
+	t.Run("paused", func(t *testing.T) {
+		c := NewClockWP(100 * time.Nanosecond)
+		c.SetRate(0)
+		var prev int64
+		for i := 0; i < 1000; i++ {
+			v := c.NowUnique().UnixNano()
+			if v <= prev {
+				t.Fatalf("value must increase: %d <= %d", v, prev)
+			}
+			prev = v
+		}
+	})
+	t.Run("frozen", func(t *testing.T) {
+		c := NewClockWP(100 * time.Nanosecond)
+		c.Backward = BackwardFreeze
+		c.Jump(0)
+		c.Jump(-time.Hour)
+		var prev int64
+		for i := 0; i < 1000; i++ {
+			v := c.NowUnique().UnixNano()
+			if v <= prev {
+				t.Fatalf("value must increase: %d <= %d", v, prev)
+			}
+			prev = v
+		}
+	})
+}
+
+func BenchmarkContention(b *testing.B) {
+	b.Run("clock.Now()", func(b *testing.B) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = c.Now()
+			}
+		})
+	})
+	b.Run("coarse.Now()", func(b *testing.B) {
+		var c Coarse
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = c.Now()
+			}
+		})
+	})
+	b.Run("native.Now()", func(b *testing.B) {
+		var c Native
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = c.Now()
+			}
+		})
+	})
+}
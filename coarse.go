@@ -0,0 +1,28 @@
+package clock
+
+import "time"
+
+// Coarse is a tickless clock reading coarse kernel clocks (CLOCK_REALTIME_COARSE and CLOCK_MONOTONIC_COARSE) on
+// demand. Unlike Clock it doesn't need a ticker goroutine, so idle processes don't pay for it. Precision is a kernel
+// tick (usually 1-4ms), see CoarseResolution.
+//
+// Go can't call vDSO directly, so each reading on linux costs a raw clock_gettime syscall, which is slower than
+// time.Now(). Coarse trades read speed for zero idle cost; for hot paths use running Clock (see BenchmarkContention).
+//
+// On platforms without coarse clocks Coarse falls back to time package.
+type Coarse struct{}
+
+// Now returns current coarse time.
+func (c Coarse) Now() time.Time {
+	return time.Unix(0, coarseRealtime())
+}
+
+// Mono returns coarse monotonic reading.
+func (c Coarse) Mono() int64 {
+	return coarseMono()
+}
+
+var (
+	_ Interface = Coarse{}
+	_ Monotonic = Coarse{}
+)
@@ -0,0 +1,46 @@
+//go:build linux
+
+package clock
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	clockRealtimeCoarse  = 5
+	clockMonotonicCoarse = 6
+)
+
+// coarseOrigin binds coarse monotonic clock to process-wide origin of monotonic readings.
+var coarseOrigin = coarseGettime(clockMonotonicCoarse) - monoNow()
+
+func coarseGettime(id uintptr) int64 {
+	var ts syscall.Timespec
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CLOCK_GETTIME, id, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		// Kernels older than 2.6.32 don't support coarse clocks.
+		if id == clockMonotonicCoarse {
+			return monoNow()
+		}
+		return time.Now().UnixNano()
+	}
+	return ts.Nano()
+}
+
+func coarseRealtime() int64 {
+	return coarseGettime(clockRealtimeCoarse)
+}
+
+func coarseMono() int64 {
+	return coarseGettime(clockMonotonicCoarse) - coarseOrigin
+}
+
+// CoarseResolution returns precision of Coarse clock.
+func CoarseResolution() time.Duration {
+	var ts syscall.Timespec
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CLOCK_GETRES, clockRealtimeCoarse, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return 1
+	}
+	return time.Duration(ts.Nano())
+}
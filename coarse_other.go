@@ -0,0 +1,18 @@
+//go:build !linux
+
+package clock
+
+import "time"
+
+func coarseRealtime() int64 {
+	return time.Now().UnixNano()
+}
+
+func coarseMono() int64 {
+	return monoNow()
+}
+
+// CoarseResolution returns precision of Coarse clock.
+func CoarseResolution() time.Duration {
+	return 1
+}
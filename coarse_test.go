@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoarse(t *testing.T) {
+	var c Coarse
+	res := CoarseResolution()
+	if res <= 0 || res > 100*time.Millisecond {
+		t.Fatalf("unexpected resolution: %s", res)
+	}
+	tol := 2*res + time.Millisecond
+	if d := time.Since(c.Now()); d < -tol || d > tol {
+		t.Errorf("coarse time drift: %s", d)
+	}
+	if d := monoNow() - c.Mono(); d < -int64(tol) || d > int64(tol) {
+		t.Errorf("coarse monotonic drift: %d", d)
+	}
+	prev := c.Mono()
+	for i := 0; i < 1000; i++ {
+		m := c.Mono()
+		if m < prev {
+			t.Fatalf("monotonic reading decreased: %d < %d", m, prev)
+		}
+		prev = m
+	}
+	sw := NewStopwatch(c)
+	time.Sleep(20 * time.Millisecond)
+	if d := sw.Elapsed(); d < 20*time.Millisecond-tol || d > time.Second {
+		t.Errorf("stopwatch mismatch: %s", d)
+	}
+}
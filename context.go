@@ -0,0 +1,106 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// deadlineCtx is a context cancelled by deadline in clock time.
+//
+// It doesn't wrap context.WithCancel: children made by context package then watch Done() and take Err() of this
+// context, so DeadlineExceeded propagates down the chain.
+type deadlineCtx struct {
+	context.Context
+	deadline time.Time
+	done     chan struct{}
+
+	mux   sync.Mutex
+	err   error
+	timer Timer
+}
+
+// WithDeadline returns a copy of parent context cancelled when clock c reaches deadline d.
+//
+// If c implements TimerInterface (Clock, Native, Stuck), its timers are used, so advancing a controllable clock
+// (Jump, Set, SetRate) cancels the context deterministically. Otherwise, real timer of remaining duration is used.
+// Deadline() returns the earlier of d and parent deadline, though parent may use another clock.
+func WithDeadline(parent context.Context, c Interface, d time.Time) (context.Context, context.CancelFunc) {
+	x := &deadlineCtx{
+		Context:  parent,
+		deadline: d,
+		done:     make(chan struct{}),
+	}
+	cancel := func() { x.fire(context.Canceled) }
+	if err := parent.Err(); err != nil {
+		x.fire(err)
+		return x, cancel
+	}
+	dur := d.Sub(c.Now())
+	if dur <= 0 {
+		x.fire(context.DeadlineExceeded)
+		return x, cancel
+	}
+	t := afterFunc(c, dur, func() { x.fire(context.DeadlineExceeded) })
+	x.mux.Lock()
+	x.timer = t
+	fired := x.err != nil
+	x.mux.Unlock()
+	if fired {
+		t.Stop()
+		return x, cancel
+	}
+	if pdone := parent.Done(); pdone != nil {
+		go func() {
+			select {
+			case <-pdone:
+				x.fire(parent.Err())
+			case <-x.done:
+			}
+		}()
+	}
+	return x, cancel
+}
+
+// WithTimeout returns WithDeadline(parent, c, c.Now().Add(timeout)).
+func WithTimeout(parent context.Context, c Interface, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return WithDeadline(parent, c, c.Now().Add(timeout))
+}
+
+func (x *deadlineCtx) Deadline() (time.Time, bool) {
+	if d, ok := x.Context.Deadline(); ok && d.Before(x.deadline) {
+		return d, true
+	}
+	return x.deadline, true
+}
+
+func (x *deadlineCtx) Done() <-chan struct{} {
+	return x.done
+}
+
+func (x *deadlineCtx) Err() error {
+	x.mux.Lock()
+	defer x.mux.Unlock()
+	return x.err
+}
+
+// fire cancels context with given error. The first call wins.
+func (x *deadlineCtx) fire(err error) {
+	x.mux.Lock()
+	if x.err != nil {
+		x.mux.Unlock()
+		return
+	}
+	x.err = err
+	close(x.done)
+	t := x.timer
+	x.mux.Unlock()
+	// Release timer of cancelled context.
+	if t != nil {
+		t.Stop()
+	}
+}
+
+func (x *deadlineCtx) String() string {
+	return "clock.WithDeadline(" + x.deadline.String() + ")"
+}
@@ -0,0 +1,129 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type plainClock struct{}
+
+func (plainClock) Now() time.Time { return time.Now() }
+
+func TestContext(t *testing.T) {
+	done := func(ctx context.Context) bool {
+		select {
+		case <-ctx.Done():
+			return true
+		default:
+			return false
+		}
+	}
+	t.Run("jump", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		ctx, cancel := WithTimeout(context.Background(), c, time.Hour)
+		defer cancel()
+		if dl, ok := ctx.Deadline(); !ok || dl.Sub(c.Now()) > time.Hour {
+			t.Errorf("deadline mismatch: %s", dl)
+		}
+		c.Jump(59 * time.Minute)
+		if done(ctx) || ctx.Err() != nil {
+			t.Error("context must not be done")
+		}
+		child, ccancel := context.WithCancel(ctx)
+		defer ccancel()
+		c.Jump(time.Minute)
+		if !done(ctx) || ctx.Err() != context.DeadlineExceeded {
+			t.Errorf("context must be done by deadline, err %v", ctx.Err())
+		}
+		<-child.Done()
+		if !errors.Is(child.Err(), context.DeadlineExceeded) {
+			t.Errorf("child must inherit deadline error, err %v", child.Err())
+		}
+	})
+	t.Run("set", func(t *testing.T) {
+		c := NewClock()
+		x := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		c.Set(x)
+		ctx, cancel := WithDeadline(context.Background(), c, x.Add(time.Second))
+		defer cancel()
+		c.Set(x.Add(time.Second))
+		if ctx.Err() != context.DeadlineExceeded {
+			t.Errorf("context must be done by deadline, err %v", ctx.Err())
+		}
+	})
+	t.Run("cancel", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		ctx, cancel := WithTimeout(context.Background(), c, time.Hour)
+		cancel()
+		if !done(ctx) || ctx.Err() != context.Canceled {
+			t.Errorf("context must be cancelled, err %v", ctx.Err())
+		}
+		c.Jump(time.Hour)
+		if ctx.Err() != context.Canceled {
+			t.Errorf("deadline must not override cancel, err %v", ctx.Err())
+		}
+	})
+	t.Run("parent", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		parent, pcancel := context.WithCancel(context.Background())
+		ctx, cancel := WithTimeout(parent, c, time.Hour)
+		defer cancel()
+		if dl, _ := ctx.Deadline(); dl.Sub(c.Now()) < 59*time.Minute {
+			t.Errorf("deadline mismatch: %s", dl)
+		}
+		pctx, pcancel1 := context.WithTimeout(parent, time.Minute)
+		defer pcancel1()
+		ctx1, cancel1 := WithTimeout(pctx, c, time.Hour)
+		defer cancel1()
+		if dl, _ := ctx1.Deadline(); dl.After(time.Now().Add(time.Minute)) {
+			t.Errorf("earlier parent deadline must be returned: %s", dl)
+		}
+		pcancel()
+		<-ctx.Done()
+		if ctx.Err() != context.Canceled {
+			t.Errorf("context must be cancelled by parent, err %v", ctx.Err())
+		}
+	})
+	t.Run("native", func(t *testing.T) {
+		ctx, cancel := WithTimeout(context.Background(), Native{}, 5*time.Millisecond)
+		defer cancel()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Error("context must be done")
+		}
+		if ctx.Err() != context.DeadlineExceeded {
+			t.Errorf("err mismatch: %v", ctx.Err())
+		}
+	})
+	t.Run("fallback", func(t *testing.T) {
+		ctx, cancel := WithTimeout(context.Background(), plainClock{}, 5*time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		if ctx.Err() != context.DeadlineExceeded {
+			t.Errorf("err mismatch: %v", ctx.Err())
+		}
+	})
+	t.Run("stuck", func(t *testing.T) {
+		c := NewStuck(1645495200, 0)
+		ctx, cancel := WithDeadline(context.Background(), c, c.Now())
+		defer cancel()
+		if !done(ctx) || ctx.Err() != context.DeadlineExceeded {
+			t.Errorf("past deadline must cancel context, err %v", ctx.Err())
+		}
+		ctx1, cancel1 := WithTimeout(context.Background(), c, time.Millisecond)
+		defer cancel1()
+		time.Sleep(5 * time.Millisecond)
+		if done(ctx1) {
+			t.Error("stuck clock never reaches deadline")
+		}
+	})
+}
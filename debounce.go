@@ -0,0 +1,208 @@
+package clock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Debouncer calls function once after quiet period since the last event, e.g. "200ms after the last keystroke".
+//
+// Events cost an atomic store: one timer is armed per quiet period and re-armed for the rest of period on expiry if
+// events kept coming. Timers of clock are used if it implements TimerInterface, so Jump fires debounced function
+// deterministically. Function is called by timer (see Clock.AfterFunc) and should not block.
+type Debouncer struct {
+	// Time of the last event (ns).
+	last  int64
+	armed uint32
+
+	clock Interface
+	wait  time.Duration
+	fn    func()
+
+	mux   sync.Mutex
+	timer Timer
+}
+
+// NewDebouncer makes debouncer calling fn after wait since the last event. Nil clock means Native.
+func NewDebouncer(clock Interface, wait time.Duration, fn func()) *Debouncer {
+	if clock == nil {
+		clock = Native{}
+	}
+	return &Debouncer{clock: clock, wait: wait, fn: fn}
+}
+
+// Trigger registers an event.
+func (d *Debouncer) Trigger() {
+	atomic.StoreInt64(&d.last, d.clock.Now().UnixNano())
+	if atomic.LoadUint32(&d.armed) == 0 && atomic.CompareAndSwapUint32(&d.armed, 0, 1) {
+		d.arm(d.wait)
+	}
+}
+
+// Stop cancels pending call. Returns false if there is no pending call.
+func (d *Debouncer) Stop() bool {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if d.timer != nil && d.timer.Stop() {
+		atomic.StoreUint32(&d.armed, 0)
+		return true
+	}
+	return false
+}
+
+// Flush calls pending function immediately. Returns false if there is no pending call.
+func (d *Debouncer) Flush() bool {
+	if !d.Stop() {
+		return false
+	}
+	d.fn()
+	return true
+}
+
+func (d *Debouncer) arm(wait time.Duration) {
+	d.mux.Lock()
+	d.timer = afterFunc(d.clock, wait, d.expire)
+	d.mux.Unlock()
+}
+
+func (d *Debouncer) expire() {
+	atomic.StoreUint32(&d.armed, 0)
+	rest := time.Duration(atomic.LoadInt64(&d.last) + int64(d.wait) - d.clock.Now().UnixNano())
+	if rest > 0 {
+		// Events kept coming, wait for the rest of quiet period. Concurrent event may arm timer first.
+		if atomic.CompareAndSwapUint32(&d.armed, 0, 1) {
+			d.arm(rest)
+		}
+		return
+	}
+	d.fn()
+}
+
+// Throttler calls function at most once per period, e.g. "at most once per second". Allowed events call function
+// immediately in caller's goroutine. Events within the period are dropped, in Trailing mode they lead to one call at
+// the end of the period.
+type Throttler struct {
+	// Time of the last call (ns).
+	last    int64
+	pending uint32
+
+	// Trailing mode calls function at the end of period if events were dropped. Must be set before use.
+	Trailing bool
+
+	clock Interface
+	every int64
+	fn    func()
+}
+
+// NewThrottler makes throttler calling fn at most once per every period. Nil clock means Native.
+func NewThrottler(clock Interface, every time.Duration, fn func()) *Throttler {
+	if clock == nil {
+		clock = Native{}
+	}
+	return &Throttler{clock: clock, every: int64(every), fn: fn}
+}
+
+// Trigger registers an event. Returns true if function was called.
+func (t *Throttler) Trigger() bool {
+	now := t.clock.Now().UnixNano()
+	last := atomic.LoadInt64(&t.last)
+	if (last == 0 || now-last >= t.every) && atomic.CompareAndSwapInt64(&t.last, last, now) {
+		t.fn()
+		return true
+	}
+	if t.Trailing && atomic.CompareAndSwapUint32(&t.pending, 0, 1) {
+		afterFunc(t.clock, time.Duration(last+t.every-now), t.trail)
+	}
+	return false
+}
+
+func (t *Throttler) trail() {
+	atomic.StoreUint32(&t.pending, 0)
+	atomic.StoreInt64(&t.last, t.clock.Now().UnixNano())
+	t.fn()
+}
+
+// Batcher collects items and flushes them in batches by size or age, e.g. "every 50ms or 1000 items".
+// Age is counted from the first item of batch by timer of clock (see Debouncer). Aged batch is flushed by the timer
+// (see Clock.AfterFunc), so flush function should not block: hand slow work (e.g. I/O) off to another goroutine.
+type Batcher[T any] struct {
+	clock Interface
+	size  int
+	every time.Duration
+	flush func(batch []T)
+
+	mux   sync.Mutex
+	buf   []T
+	gen   uint64
+	timer Timer
+}
+
+// NewBatcher makes batcher calling flush with batches up to size items or every period. Zero size means no size
+// limit. Flushed batch is owned by flush function. Nil clock means Native. Panics if size is negative.
+func NewBatcher[T any](clock Interface, size int, every time.Duration, flush func(batch []T)) *Batcher[T] {
+	if size < 0 {
+		panic("clock: batcher size must not be negative")
+	}
+	if clock == nil {
+		clock = Native{}
+	}
+	return &Batcher[T]{clock: clock, size: size, every: every, flush: flush}
+}
+
+// Add adds item to the batch. Full batch is flushed in caller's goroutine.
+func (b *Batcher[T]) Add(item T) {
+	b.mux.Lock()
+	if b.buf == nil {
+		b.buf = make([]T, 0, b.size)
+	}
+	b.buf = append(b.buf, item)
+	if len(b.buf) == 1 && b.every > 0 {
+		gen := b.gen
+		b.timer = afterFunc(b.clock, b.every, func() { b.expire(gen) })
+	}
+	var batch []T
+	if len(b.buf) >= b.size && b.size > 0 {
+		batch = b.take()
+	}
+	b.mux.Unlock()
+	if batch != nil {
+		b.flush(batch)
+	}
+}
+
+// Flush flushes current batch immediately.
+func (b *Batcher[T]) Flush() {
+	b.mux.Lock()
+	batch := b.take()
+	b.mux.Unlock()
+	if len(batch) > 0 {
+		b.flush(batch)
+	}
+}
+
+// take detaches current batch. Must be called under lock.
+func (b *Batcher[T]) take() []T {
+	batch := b.buf
+	b.buf = nil
+	b.gen++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	return batch
+}
+
+func (b *Batcher[T]) expire(gen uint64) {
+	b.mux.Lock()
+	if gen != b.gen {
+		// Batch has been flushed by size.
+		b.mux.Unlock()
+		return
+	}
+	batch := b.take()
+	b.mux.Unlock()
+	if len(batch) > 0 {
+		b.flush(batch)
+	}
+}
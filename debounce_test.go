@@ -0,0 +1,118 @@
+package clock
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	var n uint32
+	c := NewClock()
+	c.SetRate(0)
+	d := NewDebouncer(c, 200*time.Millisecond, func() { atomic.AddUint32(&n, 1) })
+	for i := 0; i < 10; i++ {
+		d.Trigger()
+		c.Jump(100 * time.Millisecond)
+	}
+	if atomic.LoadUint32(&n) != 0 {
+		t.Fatal("debounced function must wait for quiet period")
+	}
+	c.Jump(100 * time.Millisecond)
+	if atomic.LoadUint32(&n) != 1 {
+		t.Fatal("debounced function must fire after quiet period")
+	}
+	c.Jump(time.Second)
+	if atomic.LoadUint32(&n) != 1 {
+		t.Fatal("debounced function must fire once")
+	}
+	d.Trigger()
+	if !d.Flush() || atomic.LoadUint32(&n) != 2 {
+		t.Fatal("flush must fire pending function")
+	}
+	d.Trigger()
+	if !d.Stop() || d.Stop() {
+		t.Fatal("stop must cancel pending function once")
+	}
+	c.Jump(time.Second)
+	if atomic.LoadUint32(&n) != 2 {
+		t.Fatal("stopped function must not fire")
+	}
+}
+
+func TestThrottler(t *testing.T) {
+	var n uint32
+	c := NewClock()
+	c.SetRate(0)
+	th := NewThrottler(c, time.Second, func() { atomic.AddUint32(&n, 1) })
+	th.Trailing = true
+	if !th.Trigger() || th.Trigger() || th.Trigger() {
+		t.Fatal("throttled function must fire once per period")
+	}
+	c.Jump(999 * time.Millisecond)
+	if atomic.LoadUint32(&n) != 1 {
+		t.Fatal("trailing call must wait for the end of period")
+	}
+	c.Jump(time.Millisecond)
+	if atomic.LoadUint32(&n) != 2 {
+		t.Fatal("dropped events must lead to trailing call")
+	}
+	if th.Trigger() {
+		t.Fatal("trailing call starts new period")
+	}
+	c.Jump(time.Second)
+	if atomic.LoadUint32(&n) != 3 {
+		t.Fatal("dropped event must lead to trailing call")
+	}
+	c.Jump(time.Second)
+	if !th.Trigger() || atomic.LoadUint32(&n) != 4 {
+		t.Fatal("event after period must fire")
+	}
+}
+
+func TestBatcher(t *testing.T) {
+	var batches [][]int
+	c := NewClock()
+	c.SetRate(0)
+	b := NewBatcher(c, 3, 50*time.Millisecond, func(batch []int) { batches = append(batches, batch) })
+	for i := 0; i < 4; i++ {
+		b.Add(i)
+	}
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("full batch must flush: %v", batches)
+	}
+	c.Jump(40 * time.Millisecond)
+	b.Add(4)
+	if len(batches) != 1 {
+		t.Fatalf("young batch must wait: %v", batches)
+	}
+	c.Jump(10 * time.Millisecond)
+	if len(batches) != 2 || len(batches[1]) != 2 {
+		t.Fatalf("old batch must flush: %v", batches)
+	}
+	b.Add(5)
+	b.Flush()
+	c.Jump(time.Second)
+	if len(batches) != 3 || batches[2][0] != 5 {
+		t.Fatalf("flush mismatch: %v", batches)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("negative size must panic")
+			}
+		}()
+		NewBatcher(c, -1, time.Second, func([]int) {})
+	}()
+}
+
+func BenchmarkDebouncer(b *testing.B) {
+	c := NewClock()
+	c.Start()
+	defer c.Stop()
+	d := NewDebouncer(c, time.Second, func() {})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d.Trigger()
+	}
+}
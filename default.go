@@ -0,0 +1,138 @@
+package clock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Scheduler is an extension of Interface providing periodic jobs.
+type Scheduler interface {
+	Interface
+	Schedule(dur time.Duration, fn func())
+	// Every is like Schedule, but returns handle to unregister the job.
+	Every(dur time.Duration, fn func()) Timer
+}
+
+// defaultHolder wraps Interface to store it in atomic.Value.
+type defaultHolder struct {
+	c Interface
+}
+
+var (
+	// Current default clock, see Default().
+	defaultCurr atomic.Value
+	// Shared clock state protected by defaultMux.
+	defaultMux     sync.Mutex
+	defaultShared  *Clock
+	defaultRefs    int
+	defaultRunning bool
+	defaultCustom  Interface
+	// Serializes start/stop of the shared clock.
+	defaultLife sync.Mutex
+)
+
+// Default returns process-wide default clock: custom clock set by SetDefault, running shared clock or Native if
+// nobody holds the shared clock.
+func Default() Interface {
+	if h, ok := defaultCurr.Load().(defaultHolder); ok && h.c != nil {
+		return h.c
+	}
+	return Native{}
+}
+
+// SetDefault replaces default clock (e.g. by Stuck in tests). Nil restores the shared clock.
+func SetDefault(c Interface) {
+	defaultMux.Lock()
+	defer defaultMux.Unlock()
+	defaultCustom = c
+	defaultUpdate()
+}
+
+// Acquire takes a reference to the shared clock and starts it on first reference.
+// Each Acquire must be paired with Release.
+func Acquire() {
+	defaultMux.Lock()
+	defaultRefs++
+	defaultMux.Unlock()
+	defaultSync()
+}
+
+// Release drops a reference to the shared clock and stops it when the last reference is gone.
+// Until the next Acquire package-level helpers fall back to time package. Don't release the last reference from
+// scheduled job, since stopping clock waits for in-flight jobs.
+func Release() {
+	defaultMux.Lock()
+	if defaultRefs > 0 {
+		defaultRefs--
+	}
+	defaultMux.Unlock()
+	defaultSync()
+}
+
+// defaultSync starts or stops the shared clock according to references count.
+// Start/stop happen outside of defaultMux: in-flight jobs may call package-level helpers.
+func defaultSync() {
+	defaultLife.Lock()
+	defer defaultLife.Unlock()
+	defaultMux.Lock()
+	want := defaultRefs > 0
+	if want && defaultShared == nil {
+		defaultShared = NewClock()
+	}
+	c := defaultShared
+	defaultMux.Unlock()
+	if c == nil {
+		return
+	}
+	if want {
+		c.Start()
+	}
+	defaultMux.Lock()
+	// Publish running clock only; stopped clock is unpublished before stop.
+	defaultRunning = want
+	defaultUpdate()
+	defaultMux.Unlock()
+	if !want {
+		c.Stop()
+	}
+}
+
+// defaultUpdate publishes current default clock. Must be called under defaultMux.
+func defaultUpdate() {
+	var c Interface
+	switch {
+	case defaultCustom != nil:
+		c = defaultCustom
+	case defaultRunning:
+		c = defaultShared
+	}
+	defaultCurr.Store(defaultHolder{c: c})
+}
+
+// Now returns current time of default clock.
+func Now() time.Time {
+	return Default().Now()
+}
+
+// Since returns time elapsed since t by default clock.
+func Since(t time.Time) time.Duration {
+	return Default().Now().Sub(t)
+}
+
+// Schedule registers periodic job in default clock. Custom clock set by SetDefault is used if it implements
+// Scheduler, otherwise the job takes a permanent reference to the shared clock.
+func Schedule(dur time.Duration, fn func()) {
+	defaultMux.Lock()
+	custom := defaultCustom
+	defaultMux.Unlock()
+	if s, ok := custom.(Scheduler); ok {
+		s.Schedule(dur, fn)
+		return
+	}
+	Acquire()
+	defaultMux.Lock()
+	c := defaultShared
+	defaultMux.Unlock()
+	c.Schedule(dur, fn)
+}
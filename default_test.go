@@ -0,0 +1,64 @@
+package clock
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDefault(t *testing.T) {
+	t.Run("lazy", func(t *testing.T) {
+		if _, ok := Default().(Native); !ok {
+			t.Fatalf("idle default clock must be native, got %T", Default())
+		}
+		Acquire()
+		c, ok := Default().(*Clock)
+		if !ok || !c.Active() {
+			t.Fatal("acquired default clock must be running")
+		}
+		Acquire()
+		Release()
+		if !c.Active() {
+			t.Fatal("default clock must run while referenced")
+		}
+		Release()
+		if c.Active() {
+			t.Fatal("released default clock must stop")
+		}
+		if _, ok := Default().(Native); !ok {
+			t.Fatal("released default clock must fall back to native")
+		}
+		Release()
+	})
+	t.Run("set", func(t *testing.T) {
+		st := NewStuck(1700000000, 0)
+		SetDefault(st)
+		defer SetDefault(nil)
+		if n := Now(); n.Unix() != 1700000000 {
+			t.Errorf("default clock mismatch: %s", n)
+		}
+		if d := Since(time.Unix(1700000000-60, 0)); d != time.Minute {
+			t.Errorf("since mismatch: %s", d)
+		}
+	})
+	t.Run("schedule", func(t *testing.T) {
+		var a uint32
+		c := NewClock()
+		SetDefault(c)
+		defer SetDefault(nil)
+		Schedule(time.Minute, func() { atomic.AddUint32(&a, 1) })
+		c.Jump(2 * time.Minute)
+		if atomic.LoadUint32(&a) != 1 {
+			t.Error("job must run by custom clock")
+		}
+	})
+}
+
+func BenchmarkDefault(b *testing.B) {
+	Acquire()
+	defer Release()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Now()
+	}
+}
@@ -7,4 +7,21 @@ var (
 	ErrBadNum  = errors.New("bad span number")
 	ErrBadUnit = errors.New("bad span unit")
 	ErrBadEOF  = errors.New("unexpected end of file")
+
+	ErrCalendarUnit  = errors.New("calendar unit not allowed")
+	ErrBadInterval   = errors.New("bad interval")
+	ErrBadHoliday    = errors.New("bad holiday rule")
+	ErrBadCalendar   = errors.New("bad calendar definition")
+	ErrBadRRule      = errors.New("bad recurrence rule")
+	ErrHLCOffset     = errors.New("remote HLC timestamp is too far ahead")
+	ErrBadHLC        = errors.New("bad HLC timestamp")
+	ErrBadVector     = errors.New("bad vector clock")
+	ErrClockRollback = errors.New("clock moved backwards")
+	ErrIDOverflow    = errors.New("ID space exhausted")
+	ErrBadIDConfig   = errors.New("bad ID generator config")
+	ErrBadULID       = errors.New("bad ULID")
+	ErrBadUUID       = errors.New("bad UUID")
+	ErrLimitExceeded = errors.New("rate limit exceeded")
+	ErrJobPanic      = errors.New("job panicked")
+	ErrBadJobStore   = errors.New("corrupted job store")
 )
@@ -1,6 +1,7 @@
 package clock
 
 import (
+	"strconv"
 	"strings"
 	"time"
 
@@ -42,6 +43,28 @@ func TimeToBytes(dst []byte, val any, args ...any) ([]byte, error) {
 	return dst, err
 }
 
+// LogicalToBytes converts from logical clocks and their timestamps (text encoding).
+func LogicalToBytes(dst []byte, val any, _ ...any) ([]byte, error) {
+	switch x := val.(type) {
+	case HLCTimestamp:
+		dst = x.AppendText(dst)
+	case *HLCTimestamp:
+		dst = x.AppendText(dst)
+	case Vector:
+		dst = x.AppendText(dst)
+	case *Vector:
+		dst = x.AppendText(dst)
+	case *Lamport:
+		dst = strconv.AppendUint(dst, x.Now(), 10)
+	case *HLC:
+		dst = x.Last().AppendText(dst)
+	default:
+		return dst, x2bytes.ErrUnknownType
+	}
+	return dst, nil
+}
+
 func init() {
 	x2bytes.RegisterToBytesFn(TimeToBytes)
+	x2bytes.RegisterToBytesFn(LogicalToBytes)
 }
@@ -0,0 +1,149 @@
+package clock
+
+import (
+	"encoding/binary"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	hlcLogicalBits = 16
+	hlcLogicalMask = 1<<hlcLogicalBits - 1
+)
+
+// HLCTimestamp is a hybrid logical clock timestamp packed into 64 bits: 48 bits of physical time (milliseconds since
+// Unix epoch, enough till year 10889) and 16 bits of logical counter. Packed timestamps compare as integers.
+type HLCTimestamp uint64
+
+// MakeHLCTimestamp makes timestamp from physical time in milliseconds and logical counter.
+func MakeHLCTimestamp(wall int64, logical uint16) HLCTimestamp {
+	return HLCTimestamp(uint64(wall)<<hlcLogicalBits | uint64(logical))
+}
+
+// Wall returns physical part of timestamp in milliseconds since Unix epoch.
+func (t HLCTimestamp) Wall() int64 {
+	return int64(t >> hlcLogicalBits)
+}
+
+// Logical returns logical part of timestamp.
+func (t HLCTimestamp) Logical() uint16 {
+	return uint16(t & hlcLogicalMask)
+}
+
+// Time returns physical part of timestamp as time.
+func (t HLCTimestamp) Time() time.Time {
+	return time.UnixMilli(t.Wall())
+}
+
+// Compare returns -1, 0 or 1 if t happened before, same as or after u.
+func (t HLCTimestamp) Compare(u HLCTimestamp) int {
+	switch {
+	case t < u:
+		return -1
+	case t > u:
+		return 1
+	}
+	return 0
+}
+
+// AppendBinary appends 8-byte big-endian encoding of timestamp to dst. Encoded timestamps compare as byte strings.
+func (t HLCTimestamp) AppendBinary(dst []byte) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(t))
+	return append(dst, buf[:]...)
+}
+
+// AppendText appends text form "<wall>.<logical>" of timestamp to dst.
+func (t HLCTimestamp) AppendText(dst []byte) []byte {
+	dst = strconv.AppendInt(dst, t.Wall(), 10)
+	dst = append(dst, '.')
+	return strconv.AppendUint(dst, uint64(t.Logical()), 10)
+}
+
+func (t HLCTimestamp) String() string {
+	return string(t.AppendText(nil))
+}
+
+// DecodeHLCTimestamp decodes timestamp encoded by AppendBinary.
+func DecodeHLCTimestamp(p []byte) (HLCTimestamp, error) {
+	if len(p) != 8 {
+		return 0, ErrBadHLC
+	}
+	return HLCTimestamp(binary.BigEndian.Uint64(p)), nil
+}
+
+// next returns the least timestamp greater than t. Logical overflow carries into physical part.
+func (t HLCTimestamp) next() HLCTimestamp {
+	return t + 1
+}
+
+// HLC is a hybrid logical clock. It issues timestamps close to physical time of underlying clock that respect
+// causality: timestamp of event is greater than timestamps of all events known to the node.
+//
+// See "Logical Physical Clocks and Consistent Snapshots in Globally Distributed Databases" (Kulkarni et al.).
+type HLC struct {
+	// The latest issued timestamp.
+	last uint64
+	// Max allowed offset of remote clock, see Update(). Zero disables the check.
+	MaxOffset time.Duration
+
+	clock Interface
+}
+
+// NewHLC makes new hybrid logical clock over given clock. Nil clock means Native.
+func NewHLC(clock Interface, maxOffset time.Duration) *HLC {
+	if clock == nil {
+		clock = Native{}
+	}
+	return &HLC{clock: clock, MaxOffset: maxOffset}
+}
+
+// Now returns timestamp for local or send event.
+func (h *HLC) Now() HLCTimestamp {
+	pt := h.clock.Now().UnixMilli()
+	for {
+		last := HLCTimestamp(atomic.LoadUint64(&h.last))
+		next := MakeHLCTimestamp(pt, 0)
+		if next <= last {
+			next = last.next()
+		}
+		if atomic.CompareAndSwapUint64(&h.last, uint64(last), uint64(next)) {
+			return next
+		}
+	}
+}
+
+// Update merges remote timestamp on receive event and returns timestamp of the event.
+// Remote timestamp ahead of local physical time by more than MaxOffset is rejected with ErrHLCOffset and doesn't
+// affect the clock.
+func (h *HLC) Update(remote HLCTimestamp) (HLCTimestamp, error) {
+	pt := h.clock.Now().UnixMilli()
+	if h.MaxOffset > 0 && remote.Wall()-pt > h.MaxOffset.Milliseconds() {
+		return 0, ErrHLCOffset
+	}
+	for {
+		last := HLCTimestamp(atomic.LoadUint64(&h.last))
+		// Packed timestamps compare as (wall, logical) pairs, so the rule of the paper reduces to max+1 unless
+		// physical time is ahead of both.
+		next := MakeHLCTimestamp(pt, 0)
+		if max := maxHLC(last, remote); next <= max {
+			next = max.next()
+		}
+		if atomic.CompareAndSwapUint64(&h.last, uint64(last), uint64(next)) {
+			return next, nil
+		}
+	}
+}
+
+// Last returns the latest issued timestamp.
+func (h *HLC) Last() HLCTimestamp {
+	return HLCTimestamp(atomic.LoadUint64(&h.last))
+}
+
+func maxHLC(a, b HLCTimestamp) HLCTimestamp {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,88 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHLC(t *testing.T) {
+	const wall = 1700000000000
+	t.Run("now", func(t *testing.T) {
+		h := NewHLC(NewStuck(wall/1000, 0), 0)
+		for i := 0; i < 3; i++ {
+			if ts := h.Now(); ts.Wall() != wall || ts.Logical() != uint16(i) {
+				t.Errorf("timestamp mismatch: %s", ts)
+			}
+		}
+	})
+	t.Run("advance", func(t *testing.T) {
+		c := NewClock()
+		c.Jump(0)
+		h := NewHLC(c, 0)
+		ts0, ts1 := h.Now(), h.Now()
+		c.Jump(time.Second)
+		ts2 := h.Now()
+		if ts1.Compare(ts0) != 1 || ts2.Compare(ts1) != 1 {
+			t.Errorf("timestamps must increase: %s %s %s", ts0, ts1, ts2)
+		}
+		if ts2.Logical() != 0 || ts2.Wall()-ts1.Wall() < 1000 {
+			t.Errorf("physical time must reset logical counter: %s", ts2)
+		}
+		c.Jump(-time.Hour)
+		if ts3 := h.Now(); ts3.Compare(ts2) != 1 || ts3.Wall() != ts2.Wall() {
+			t.Errorf("backward step must keep causality: %s", ts3)
+		}
+	})
+	t.Run("update", func(t *testing.T) {
+		h := NewHLC(NewStuck(wall/1000, 0), time.Second)
+		_ = h.Now()
+		// Remote node is ahead by 500ms.
+		ts, err := h.Update(MakeHLCTimestamp(wall+500, 7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts != MakeHLCTimestamp(wall+500, 8) {
+			t.Errorf("update mismatch: %s", ts)
+		}
+		if ts = h.Now(); ts != MakeHLCTimestamp(wall+500, 9) {
+			t.Errorf("timestamp after update mismatch: %s", ts)
+		}
+		// Remote node is behind.
+		if ts, _ = h.Update(MakeHLCTimestamp(wall-500, 100)); ts != MakeHLCTimestamp(wall+500, 10) {
+			t.Errorf("update mismatch: %s", ts)
+		}
+		// Remote node is too far ahead.
+		if _, err = h.Update(MakeHLCTimestamp(wall+2000, 0)); err != ErrHLCOffset {
+			t.Errorf("offset error expected, got %v", err)
+		}
+		if ts = h.Last(); ts != MakeHLCTimestamp(wall+500, 10) {
+			t.Errorf("rejected update must not affect clock: %s", ts)
+		}
+	})
+	t.Run("overflow", func(t *testing.T) {
+		h := NewHLC(NewStuck(wall/1000, 0), 0)
+		_, _ = h.Update(MakeHLCTimestamp(wall, hlcLogicalMask-1))
+		if ts := h.Now(); ts != MakeHLCTimestamp(wall+1, 0) {
+			t.Errorf("logical overflow must carry: %s", ts)
+		}
+	})
+	t.Run("encoding", func(t *testing.T) {
+		a, b := MakeHLCTimestamp(wall, 65535), MakeHLCTimestamp(wall+1, 0)
+		pa, pb := a.AppendBinary(nil), b.AppendBinary(nil)
+		if len(pa) != 8 || string(pa) >= string(pb) {
+			t.Errorf("binary encoding must keep order: %x %x", pa, pb)
+		}
+		if x, err := DecodeHLCTimestamp(pb); err != nil || x != b {
+			t.Errorf("decode mismatch: %s %v", x, err)
+		}
+		if _, err := DecodeHLCTimestamp(pb[:7]); err != ErrBadHLC {
+			t.Errorf("decode error expected, got %v", err)
+		}
+		if s := b.String(); s != "1700000000001.0" {
+			t.Errorf("text mismatch: %s", s)
+		}
+		if !b.Time().Equal(time.UnixMilli(wall + 1)) {
+			t.Errorf("time mismatch: %s", b.Time())
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package clock
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Holiday represents holiday rule.
+type Holiday interface {
+	// Date returns holiday date in given year. False means no holiday in this year.
+	Date(year int) (time.Month, int, bool)
+}
+
+// FixedHoliday is a holiday with fixed date, e.g. January 1.
+// Zero Year means holiday repeats every year.
+type FixedHoliday struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func (h FixedHoliday) Date(year int) (time.Month, int, bool) {
+	if h.Year != 0 && h.Year != year {
+		return 0, 0, false
+	}
+	if h.Day > daysIn(year, h.Month) {
+		// Feb 29 in non-leap year.
+		return 0, 0, false
+	}
+	return h.Month, h.Day, true
+}
+
+// WeekdayHoliday is a holiday on N-th weekday of month, e.g. last Monday of May.
+// Positive N counts from the beginning of month, negative from the end (-1 means last).
+type WeekdayHoliday struct {
+	N       int
+	Weekday time.Weekday
+	Month   time.Month
+}
+
+func (h WeekdayHoliday) Date(year int) (time.Month, int, bool) {
+	switch {
+	case h.N > 0:
+		first := time.Date(year, h.Month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+		d := 1 + mod(int(h.Weekday-first), 7) + (h.N-1)*7
+		if d > daysIn(year, h.Month) {
+			return 0, 0, false
+		}
+		return h.Month, d, true
+	case h.N < 0:
+		last := daysIn(year, h.Month)
+		lwd := time.Date(year, h.Month, last, 0, 0, 0, 0, time.UTC).Weekday()
+		d := last - mod(int(lwd-h.Weekday), 7) + (h.N+1)*7
+		if d < 1 {
+			return 0, 0, false
+		}
+		return h.Month, d, true
+	}
+	return 0, 0, false
+}
+
+// EasterHoliday is a holiday relative to Easter Sunday, e.g. Good Friday (Offset -2) or Easter Monday (Offset 1).
+// Orthodox flag switches to Julian computus (valid for 1900-2099).
+type EasterHoliday struct {
+	Offset   int
+	Orthodox bool
+}
+
+func (h EasterHoliday) Date(year int) (time.Month, int, bool) {
+	m, d := Easter(year, h.Orthodox)
+	t := time.Date(year, m, d+h.Offset, 0, 0, 0, 0, time.UTC)
+	if t.Year() != year {
+		return 0, 0, false
+	}
+	return t.Month(), t.Day(), true
+}
+
+// Easter returns Easter Sunday date of given year in Gregorian calendar.
+func Easter(year int, orthodox bool) (time.Month, int) {
+	if orthodox {
+		a, b, c := year%4, year%7, year%19
+		d := (19*c + 15) % 30
+		e := (2*a + 4*b - d + 34) % 7
+		m, dd := (d+e+114)/31, (d+e+114)%31+1
+		// Julian to Gregorian.
+		t := time.Date(year, time.Month(m), dd+13, 0, 0, 0, 0, time.UTC)
+		return t.Month(), t.Day()
+	}
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	return time.Month((h + l - 7*m + 114) / 31), (h+l-7*m+114)%31 + 1
+}
+
+var (
+	weekdayNames = map[string]time.Weekday{
+		"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+		"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	}
+	monthNames = map[string]time.Month{
+		"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+		"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+		"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+	}
+	ordinalNames = map[string]int{
+		"first": 1, "1st": 1, "second": 2, "2nd": 2, "third": 3, "3rd": 3, "fourth": 4, "4th": 4, "fifth": 5, "5th": 5,
+		"last": -1,
+	}
+)
+
+// lookupWeekday finds weekday by lowercase full name or 3-letter abbreviation.
+func lookupWeekday(s string) (time.Weekday, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	wd, ok := weekdayNames[s[:3]]
+	return wd, ok && (len(s) == 3 || strings.EqualFold(s, wd.String()))
+}
+
+// lookupMonth finds month by lowercase full name or 3-letter abbreviation.
+func lookupMonth(s string) (time.Month, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	m, ok := monthNames[s[:3]]
+	return m, ok && (len(s) == 3 || strings.EqualFold(s, m.String()))
+}
+
+// ParseHoliday parses holiday rule.
+//
+// Supported forms:
+// * "01-01", "12-25" - fixed date every year
+// * "2024-12-31" - fixed date
+// * "last monday of may", "3 mon jan", "2nd tue nov" - N-th weekday of month
+// * "easter", "easter-2", "easter+1", "orthodox-easter+1" - Easter relative
+func ParseHoliday(raw string) (Holiday, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if len(raw) == 0 {
+		return nil, ErrBadHoliday
+	}
+	if strings.HasPrefix(raw, "easter") || strings.HasPrefix(raw, "orthodox-easter") {
+		var h EasterHoliday
+		if h.Orthodox = strings.HasPrefix(raw, "orthodox-"); h.Orthodox {
+			raw = raw[len("orthodox-"):]
+		}
+		if raw = raw[len("easter"):]; len(raw) > 0 {
+			if raw[0] == '+' {
+				raw = raw[1:]
+			}
+			var err error
+			if h.Offset, err = strconv.Atoi(raw); err != nil {
+				return nil, ErrBadHoliday
+			}
+		}
+		return h, nil
+	}
+	if raw[0] >= '0' && raw[0] <= '9' && strings.IndexByte(raw, ' ') == -1 {
+		for _, layout := range [...]string{"2006-01-02", "01-02"} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				h := FixedHoliday{Month: t.Month(), Day: t.Day()}
+				if len(layout) > 5 {
+					h.Year = t.Year()
+				}
+				return h, nil
+			}
+		}
+		if raw == "02-29" {
+			// time.Parse rejects Feb 29 without year.
+			return FixedHoliday{Month: time.February, Day: 29}, nil
+		}
+		return nil, ErrBadHoliday
+	}
+	var (
+		h    WeekdayHoliday
+		ok   [3]bool
+		toks = strings.Fields(raw)
+	)
+	for _, tok := range toks {
+		if tok == "of" {
+			continue
+		}
+		if n, found := ordinalNames[tok]; found && !ok[0] {
+			h.N, ok[0] = n, true
+			continue
+		}
+		if n, err := strconv.Atoi(tok); err == nil && !ok[0] {
+			h.N, ok[0] = n, true
+			continue
+		}
+		if wd, found := lookupWeekday(tok); found && !ok[1] {
+			h.Weekday, ok[1] = wd, true
+			continue
+		}
+		if m, found := lookupMonth(tok); found && !ok[2] {
+			h.Month, ok[2] = m, true
+			continue
+		}
+		return nil, ErrBadHoliday
+	}
+	if !ok[0] || !ok[1] || !ok[2] || h.N == 0 || h.N > 5 || h.N < -5 {
+		return nil, ErrBadHoliday
+	}
+	return h, nil
+}
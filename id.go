@@ -0,0 +1,170 @@
+package clock
+
+import (
+	"crypto/rand"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IDOverflow describes how ID generators handle sequence overflow within one tick of time.
+type IDOverflow uint8
+
+const (
+	// OverflowWait waits for the next tick of the clock.
+	OverflowWait IDOverflow = iota
+	// OverflowBorrow takes the next tick in advance, IDs run ahead of the clock until it catches up.
+	OverflowBorrow
+)
+
+// idMaxWait limits waiting for the next tick, e.g. over stopped clock.
+const idMaxWait = time.Second
+
+// idClock is a time state of ID generator. Must be used under generator lock.
+type idClock struct {
+	// Tick of the last ID and the latest observed tick of the clock.
+	last, seen int64
+}
+
+// tick returns tick of the next ID and flag of the same tick as of the previous ID.
+// Rollbacks up to maxRollback ticks are absorbed: IDs continue from the last tick.
+func (c *idClock) tick(now, maxRollback int64) (int64, bool, error) {
+	if now < c.seen-maxRollback {
+		return 0, false, ErrClockRollback
+	}
+	if now > c.seen {
+		c.seen = now
+	}
+	if now > c.last {
+		c.last = now
+		return now, false, nil
+	}
+	return c.last, true, nil
+}
+
+// advance moves to the next tick after sequence overflow.
+func (c *idClock) advance(overflow IDOverflow, now func() int64) (int64, error) {
+	if overflow == OverflowBorrow {
+		c.last++
+		return c.last, nil
+	}
+	deadline := monoNow() + int64(idMaxWait)
+	for {
+		runtime.Gosched()
+		if n := now(); n > c.last {
+			c.last, c.seen = n, n
+			return n, nil
+		}
+		if monoNow() > deadline {
+			return 0, ErrIDOverflow
+		}
+	}
+}
+
+// randBuf is a buffered crypto random source. Must be used under generator lock.
+type randBuf struct {
+	buf [256]byte
+	n   int
+}
+
+func (r *randBuf) read(p []byte) {
+	for len(p) > 0 {
+		if r.n == 0 {
+			if _, err := rand.Read(r.buf[:]); err != nil {
+				panic(err)
+			}
+			r.n = len(r.buf)
+		}
+		c := copy(p, r.buf[len(r.buf)-r.n:])
+		r.n -= c
+		p = p[c:]
+	}
+}
+
+// SnowflakeEpoch is a default epoch of Snowflake IDs.
+var SnowflakeEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake is a generator of Snowflake-style 63-bit IDs: time since epoch, node ID and sequence within a tick.
+// Config fields must be set before the first ID.
+type Snowflake struct {
+	// Custom epoch, default is SnowflakeEpoch.
+	Epoch time.Time
+	// Time unit of a tick, default is millisecond.
+	Unit time.Duration
+	// Bits of node ID and sequence, default is 10 and 12. The rest of 63 bits keeps time.
+	NodeBits, SeqBits uint8
+	// Node ID.
+	Node uint64
+	// Sequence overflow policy.
+	Overflow IDOverflow
+	// Tolerated clock rollback: IDs continue from the last time. Bigger rollbacks fail with ErrClockRollback.
+	MaxRollback time.Duration
+
+	clock Interface
+	mux   sync.Mutex
+	ts    idClock
+	seq   uint64
+}
+
+// NewSnowflake makes new Snowflake generator of given node over given clock. Nil clock means Native.
+func NewSnowflake(clock Interface, node uint64) *Snowflake {
+	if clock == nil {
+		clock = Native{}
+	}
+	return &Snowflake{
+		Epoch:    SnowflakeEpoch,
+		Unit:     time.Millisecond,
+		NodeBits: 10,
+		SeqBits:  12,
+		Node:     node,
+		clock:    clock,
+	}
+}
+
+// Next returns the next ID.
+func (g *Snowflake) Next() (int64, error) {
+	if g.NodeBits+g.SeqBits > 62 || g.Node >= 1<<g.NodeBits || g.Unit <= 0 {
+		return 0, ErrBadIDConfig
+	}
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	tick, same, err := g.ts.tick(g.now(), int64(g.MaxRollback/g.Unit))
+	if err != nil {
+		return 0, err
+	}
+	if !same {
+		g.seq = 0
+	} else if g.seq++; g.seq >= 1<<g.SeqBits {
+		if tick, err = g.ts.advance(g.Overflow, g.now); err != nil {
+			return 0, err
+		}
+		g.seq = 0
+	}
+	if tick >= 1<<(63-g.NodeBits-g.SeqBits) {
+		return 0, ErrIDOverflow
+	}
+	return tick<<(g.NodeBits+g.SeqBits) | int64(g.Node<<g.SeqBits|g.seq), nil
+}
+
+// Append appends decimal form of the next ID to dst.
+func (g *Snowflake) Append(dst []byte) ([]byte, error) {
+	id, err := g.Next()
+	if err != nil {
+		return dst, err
+	}
+	return strconv.AppendInt(dst, id, 10), nil
+}
+
+// Decompose splits ID to time, node ID and sequence.
+func (g *Snowflake) Decompose(id int64) (t time.Time, node, seq uint64) {
+	tick := id >> (g.NodeBits + g.SeqBits)
+	t = g.Epoch.Add(time.Duration(tick) * g.Unit)
+	node = uint64(id>>g.SeqBits) & (1<<g.NodeBits - 1)
+	seq = uint64(id) & (1<<g.SeqBits - 1)
+	return
+}
+
+func (g *Snowflake) now() int64 {
+	return g.clock.Now().Sub(g.Epoch).Nanoseconds() / int64(g.Unit)
+}
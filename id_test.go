@@ -0,0 +1,173 @@
+package clock
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSnowflake(t *testing.T) {
+	t.Run("borrow", func(t *testing.T) {
+		c := NewStuck(1700000000, 0)
+		g := NewSnowflake(c, 5)
+		g.Overflow = OverflowBorrow
+		var prev int64
+		for i := 0; i < 4097; i++ {
+			id, err := g.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id <= prev {
+				t.Fatalf("ID must increase: %d <= %d", id, prev)
+			}
+			prev = id
+		}
+		ts, node, seq := g.Decompose(prev)
+		if want := c.Now().Add(time.Millisecond); !ts.Equal(want) || node != 5 || seq != 0 {
+			t.Errorf("overflow must borrow the next tick: %s %d %d", ts, node, seq)
+		}
+	})
+	t.Run("wait", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		defer c.Stop()
+		g := NewSnowflake(c, 1)
+		g.SeqBits = 8
+		var prev int64
+		for i := 0; i < 5000; i++ {
+			id, err := g.Next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id <= prev {
+				t.Fatalf("ID must increase: %d <= %d", id, prev)
+			}
+			if ts, _, _ := g.Decompose(id); ts.After(c.Now()) {
+				t.Fatalf("ID must not run ahead of clock: %s", ts)
+			}
+			prev = id
+		}
+	})
+	t.Run("rollback", func(t *testing.T) {
+		c := NewClock()
+		c.Jump(0)
+		g := NewSnowflake(c, 1)
+		id0, _ := g.Next()
+		c.Jump(-time.Second)
+		if _, err := g.Next(); err != ErrClockRollback {
+			t.Errorf("rollback error expected, got %v", err)
+		}
+		g.MaxRollback = 2 * time.Second
+		if id1, err := g.Next(); err != nil || id1 <= id0 {
+			t.Errorf("tolerated rollback must keep order: %d %v", id1, err)
+		}
+	})
+	t.Run("config", func(t *testing.T) {
+		g := NewSnowflake(nil, 1024)
+		if _, err := g.Next(); err != ErrBadIDConfig {
+			t.Errorf("config error expected, got %v", err)
+		}
+	})
+}
+
+func TestULID(t *testing.T) {
+	c := NewStuck(1700000000, 0)
+	g := NewULIDGenerator(c)
+	g.Overflow = OverflowBorrow
+	var prev []byte
+	for i := 0; i < 100; i++ {
+		u, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := u.AppendText(nil); bytes.Compare(s, prev) <= 0 {
+			t.Fatalf("ULID must increase: %s <= %s", s, prev)
+		} else {
+			prev = s
+		}
+		if !u.Time().Equal(c.Now()) {
+			t.Fatalf("time mismatch: %s", u.Time())
+		}
+		if x, err := ParseULID(u.String()); err != nil || x != u {
+			t.Fatalf("parse mismatch: %s %v", x, err)
+		}
+	}
+	for i := 6; i < 16; i++ {
+		g.last[i] = 0xff
+	}
+	if u, _ := g.Next(); !u.Time().Equal(c.Now().Add(time.Millisecond)) {
+		t.Errorf("overflow must borrow the next tick: %s", u.Time())
+	}
+	if u, err := ParseULID("01arz3ndektsv4rrffq69g5fav"); err != nil || u.String() != "01ARZ3NDEKTSV4RRFFQ69G5FAV" {
+		t.Errorf("parse mismatch: %s %v", u, err)
+	}
+	if _, err := ParseULID("81ARZ3NDEKTSV4RRFFQ69G5FAV"); err != ErrBadULID {
+		t.Errorf("parse error expected, got %v", err)
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	c := NewStuck(1700000000, 0)
+	g := NewUUIDv7Generator(c)
+	g.Overflow = OverflowBorrow
+	var prev []byte
+	for i := 0; i < 3000; i++ {
+		u, err := g.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Version() != 7 || u[8]>>6 != 2 {
+			t.Fatalf("bad version or variant: %s", u)
+		}
+		if s := u.AppendText(nil); bytes.Compare(s, prev) <= 0 {
+			t.Fatalf("UUID must increase: %s <= %s", s, prev)
+		} else {
+			prev = s
+		}
+		if x, err := ParseUUID(u.String()); err != nil || x != u {
+			t.Fatalf("parse mismatch: %s %v", x, err)
+		}
+	}
+	if u, _ := ParseUUID(string(prev)); u.Time().Before(c.Now()) {
+		t.Errorf("time mismatch: %s", u.Time())
+	}
+	if _, err := ParseUUID("017f22e2-79b0-7cc3-98c4-dc0c0c07398"); err != ErrBadUUID {
+		t.Errorf("parse error expected, got %v", err)
+	}
+}
+
+func TestIDAlloc(t *testing.T) {
+	c := NewClock()
+	c.Start()
+	defer c.Stop()
+	buf := make([]byte, 0, 64)
+	sf, ul, uu := NewSnowflake(c, 1), NewULIDGenerator(c), NewUUIDv7Generator(c)
+	sf.Overflow, ul.Overflow, uu.Overflow = OverflowBorrow, OverflowBorrow, OverflowBorrow
+	for name, fn := range map[string]func(){
+		"snowflake": func() { buf, _ = sf.Append(buf[:0]) },
+		"ulid":      func() { buf, _ = ul.Append(buf[:0]) },
+		"uuid":      func() { buf, _ = uu.Append(buf[:0]) },
+	} {
+		if n := testing.AllocsPerRun(1000, fn); n != 0 {
+			t.Errorf("%s: %f allocs", name, n)
+		}
+	}
+}
+
+func BenchmarkID(b *testing.B) {
+	c := NewClock()
+	c.Start()
+	defer c.Stop()
+	bench := func(name string, fn func([]byte) ([]byte, error)) {
+		b.Run(name, func(b *testing.B) {
+			buf := make([]byte, 0, 64)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf, _ = fn(buf[:0])
+			}
+		})
+	}
+	bench("snowflake", NewSnowflake(c, 1).Append)
+	bench("ulid", NewULIDGenerator(c).Append)
+	bench("uuid", NewUUIDv7Generator(c).Append)
+}
@@ -0,0 +1,314 @@
+package clock
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/koykov/bytealg"
+)
+
+// Interval represents half-open time range [Start, End).
+type Interval struct {
+	Start, End time.Time
+}
+
+var intervalLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// ParseInterval parses raw range relative to now in given location.
+//
+// Supported forms:
+// * "today", "yesterday", "tomorrow"
+// * "this week", "previous month", "next year" - whole calendar unit
+// * "last 7 days", "next 2h" - rolling window ending/starting at now
+// * ISO 8601 intervals: "start/end", "start/duration", "duration/end"
+// * "start..end" ranges
+//
+// Nil now means Native clock, nil location means time.Local.
+func ParseInterval(raw string, now Interface, loc *time.Location) (Interval, error) {
+	if now == nil {
+		now = Native{}
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	if raw = bytealg.TrimString(raw, " "); len(raw) == 0 {
+		return Interval{}, ErrBadInterval
+	}
+	if i := strings.IndexByte(raw, '/'); i != -1 {
+		return parseIntervalPair(raw[:i], raw[i+1:], loc)
+	}
+	if i := strings.Index(raw, ".."); i != -1 {
+		return parseIntervalPair(raw[:i], raw[i+2:], loc)
+	}
+	return parseIntervalRel(raw, now.Now().In(loc))
+}
+
+func parseIntervalRel(raw string, now time.Time) (Interval, error) {
+	lraw := strings.ToLower(raw)
+	switch lraw {
+	case "today":
+		return calendarInterval(now, 0, Day), nil
+	case "yesterday":
+		return calendarInterval(now, -1, Day), nil
+	case "tomorrow":
+		return calendarInterval(now, 1, Day), nil
+	}
+	var kw, tail string
+	if i := strings.IndexByte(lraw, ' '); i != -1 {
+		kw, tail = lraw[:i], bytealg.TrimString(raw[i+1:], " ")
+	}
+	var dir int
+	switch kw {
+	case "this", "current":
+		dir = 0
+	case "last", "past", "previous", "prev":
+		dir = -1
+	case "next":
+		dir = 1
+	default:
+		return Interval{}, ErrBadInterval
+	}
+	p := DefaultRelativeParser
+	if len(tail) > 0 && (tail[0] < '0' || tail[0] > '9') {
+		// Whole calendar unit, e.g. "previous month".
+		unit := p.lookup(tail)
+		if unit == UnitUnknown || unit < Minute {
+			return Interval{}, ErrBadInterval
+		}
+		return calendarInterval(now, dir, unit), nil
+	}
+	// Rolling window, e.g. "last 7 days".
+	if dir == 0 || kw == "previous" || kw == "prev" {
+		return Interval{}, ErrBadInterval
+	}
+	t := now
+	if _, err := p.each(tail, func(n int64, unit Unit) {
+		t = AddUnits(t, int(n)*dir, unit)
+	}); err != nil {
+		return Interval{}, err
+	}
+	if dir < 0 {
+		return Interval{Start: t, End: now}, nil
+	}
+	return Interval{Start: now, End: t}, nil
+}
+
+func parseIntervalPair(lo, hi string, loc *time.Location) (iv Interval, err error) {
+	lo, hi = bytealg.TrimString(lo, " "), bytealg.TrimString(hi, " ")
+	if len(lo) == 0 || len(hi) == 0 {
+		err = ErrBadInterval
+		return
+	}
+	var d isoDur
+	switch {
+	case lo[0] == 'P' && hi[0] == 'P':
+		err = ErrBadInterval
+	case lo[0] == 'P':
+		if d, err = parseISODur(lo); err != nil {
+			return
+		}
+		if iv.End, err = parseIntervalTime(hi, loc); err != nil {
+			return
+		}
+		iv.Start = d.apply(iv.End, -1)
+	case hi[0] == 'P':
+		if d, err = parseISODur(hi); err != nil {
+			return
+		}
+		if iv.Start, err = parseIntervalTime(lo, loc); err != nil {
+			return
+		}
+		iv.End = d.apply(iv.Start, 1)
+	default:
+		if iv.Start, err = parseIntervalTime(lo, loc); err != nil {
+			return
+		}
+		iv.End, err = parseIntervalTime(hi, loc)
+	}
+	if err == nil && iv.End.Before(iv.Start) {
+		err = ErrBadInterval
+	}
+	return
+}
+
+func parseIntervalTime(raw string, loc *time.Location) (time.Time, error) {
+	for _, layout := range intervalLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrBadInterval
+}
+
+// isoDur represents ISO 8601 duration like P1Y2M3DT4H5M6S.
+type isoDur struct {
+	y, m, d int
+	dur     time.Duration
+}
+
+func (d isoDur) apply(t time.Time, sign int) time.Time {
+	t = AddUnits(t, d.y*sign, Year)
+	t = AddUnits(t, d.m*sign, Month)
+	t = AddUnits(t, d.d*sign, Day)
+	return t.Add(d.dur * time.Duration(sign))
+}
+
+func parseISODur(raw string) (d isoDur, err error) {
+	if len(raw) < 3 || raw[0] != 'P' {
+		err = ErrBadInterval
+		return
+	}
+	// Time part designator T is allowed once and must be followed by at least one time component.
+	var tpart, ok, tok bool
+	for off := 1; off < len(raw); {
+		if raw[off] == 'T' {
+			if tpart {
+				err = ErrBadInterval
+				return
+			}
+			tpart = true
+			off++
+			continue
+		}
+		pos := off
+		for pos < len(raw) && (raw[pos] >= '0' && raw[pos] <= '9' || raw[pos] == '.' || raw[pos] == ',') {
+			pos++
+		}
+		if pos == off || pos == len(raw) {
+			err = ErrBadInterval
+			return
+		}
+		num := strings.ReplaceAll(raw[off:pos], ",", ".")
+		var f float64
+		if f, err = strconv.ParseFloat(num, 64); err != nil {
+			err = ErrBadInterval
+			return
+		}
+		n := int(f)
+		// Calendar units have no exact fraction: P1.5D is 36 hours in one day and 35 or 37 over DST change.
+		if !tpart && (float64(n) != f || f > math.MaxInt32) {
+			err = ErrBadInterval
+			return
+		}
+		switch {
+		case !tpart && raw[pos] == 'Y':
+			d.y += n
+		case !tpart && raw[pos] == 'M':
+			d.m += n
+		case !tpart && raw[pos] == 'W':
+			d.d += n * 7
+		case !tpart && raw[pos] == 'D':
+			d.d += n
+		case tpart && raw[pos] == 'H':
+			d.dur += time.Duration(f * float64(time.Hour))
+		case tpart && raw[pos] == 'M':
+			d.dur += time.Duration(f * float64(time.Minute))
+		case tpart && raw[pos] == 'S':
+			d.dur += time.Duration(f * float64(time.Second))
+		default:
+			err = ErrBadInterval
+			return
+		}
+		ok, tok = true, tpart
+		off = pos + 1
+	}
+	if !ok || tpart && !tok {
+		err = ErrBadInterval
+	}
+	return
+}
+
+// calendarInterval returns whole calendar unit containing t shifted by n units.
+func calendarInterval(t time.Time, n int, unit Unit) Interval {
+	return Interval{Start: startOfShift(t, n, unit, nil), End: startOfShift(t, n+1, unit, nil)}
+}
+
+// IsZero checks if interval is empty.
+func (iv Interval) IsZero() bool {
+	return !iv.End.After(iv.Start)
+}
+
+// Duration returns length of the interval.
+func (iv Interval) Duration() time.Duration {
+	return iv.End.Sub(iv.Start)
+}
+
+// Contains checks if t belongs to the interval.
+func (iv Interval) Contains(t time.Time) bool {
+	return !t.Before(iv.Start) && t.Before(iv.End)
+}
+
+// Overlaps checks if intervals have common part.
+func (iv Interval) Overlaps(x Interval) bool {
+	return iv.Start.Before(x.End) && x.Start.Before(iv.End)
+}
+
+// Intersect returns common part of intervals. False means intervals don't overlap.
+func (iv Interval) Intersect(x Interval) (Interval, bool) {
+	if !iv.Overlaps(x) {
+		return Interval{}, false
+	}
+	r := iv
+	if x.Start.After(r.Start) {
+		r.Start = x.Start
+	}
+	if x.End.Before(r.End) {
+		r.End = x.End
+	}
+	return r, true
+}
+
+// Union returns interval covering both intervals. False means intervals neither overlap nor adjoin.
+func (iv Interval) Union(x Interval) (Interval, bool) {
+	if iv.Start.After(x.End) || x.Start.After(iv.End) {
+		return Interval{}, false
+	}
+	r := iv
+	if x.Start.Before(r.Start) {
+		r.Start = x.Start
+	}
+	if x.End.After(r.End) {
+		r.End = x.End
+	}
+	return r, true
+}
+
+// Split splits interval to consecutive parts of given step. Last part may be shorter.
+func (iv Interval) Split(step time.Duration) []Interval {
+	// Limit preallocation: tiny step over long interval would allocate all memory at once.
+	const splitPrealloc = 1024
+	if step <= 0 || iv.IsZero() {
+		return nil
+	}
+	n := iv.Duration()/step + 1
+	if n > splitPrealloc {
+		n = splitPrealloc
+	}
+	r := make([]Interval, 0, n)
+	for t := iv.Start; t.Before(iv.End); t = t.Add(step) {
+		e := t.Add(step)
+		if e.After(iv.End) {
+			e = iv.End
+		}
+		r = append(r, Interval{Start: t, End: e})
+	}
+	return r
+}
+
+// String returns ISO 8601 representation of the interval.
+func (iv Interval) String() string {
+	return iv.Start.Format(time.RFC3339Nano) + "/" + iv.End.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,102 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	loc, _ := LoadLocation("Europe/Berlin")
+	ref := time.Date(2024, 3, 14, 10, 30, 0, 0, loc) // Thursday
+	now := NewStuck(ref.Unix(), 0)
+	d := func(y int, m time.Month, d, hh, mm int) time.Time {
+		return time.Date(y, m, d, hh, mm, 0, 0, loc)
+	}
+	stages := []struct {
+		key string
+		exp Interval
+		err error
+	}{
+		{key: "today", exp: Interval{d(2024, 3, 14, 0, 0), d(2024, 3, 15, 0, 0)}},
+		{key: "yesterday", exp: Interval{d(2024, 3, 13, 0, 0), d(2024, 3, 14, 0, 0)}},
+		{key: "Tomorrow", exp: Interval{d(2024, 3, 15, 0, 0), d(2024, 3, 16, 0, 0)}},
+		{key: "this week", exp: Interval{d(2024, 3, 11, 0, 0), d(2024, 3, 18, 0, 0)}},
+		{key: "previous month", exp: Interval{d(2024, 2, 1, 0, 0), d(2024, 3, 1, 0, 0)}},
+		{key: "last quarter", exp: Interval{d(2023, 10, 1, 0, 0), d(2024, 1, 1, 0, 0)}},
+		{key: "next year", exp: Interval{d(2025, 1, 1, 0, 0), d(2026, 1, 1, 0, 0)}},
+		{key: "this hour", exp: Interval{d(2024, 3, 14, 10, 0), d(2024, 3, 14, 11, 0)}},
+		{key: "last 7 days", exp: Interval{d(2024, 3, 7, 10, 30), ref}},
+		{key: "past 1 month 2 days", exp: Interval{d(2024, 2, 12, 10, 30), ref}},
+		{key: "next 90min", exp: Interval{ref, d(2024, 3, 14, 12, 0)}},
+		{key: "2024-01-01..2024-02-01", exp: Interval{d(2024, 1, 1, 0, 0), d(2024, 2, 1, 0, 0)}},
+		{key: "2024-01-01T00:00:00Z/2024-01-02T00:00:00Z", exp: Interval{
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+		{key: "2024-01-31/P1M", exp: Interval{d(2024, 1, 31, 0, 0), d(2024, 2, 29, 0, 0)}},
+		{key: "2024-01-01T10:00/PT1H30M", exp: Interval{d(2024, 1, 1, 10, 0), d(2024, 1, 1, 11, 30)}},
+		{key: "P1W/2024-01-08", exp: Interval{d(2024, 1, 1, 0, 0), d(2024, 1, 8, 0, 0)}},
+		{key: "2024-02-01..2024-01-01", err: ErrBadInterval},
+		{key: "P1D/P2D", err: ErrBadInterval},
+		{key: "2024-01-01/P", err: ErrBadInterval},
+		{key: "2024-01-01/P1.5D", err: ErrBadInterval},
+		{key: "P1DT/2024-01-01", err: ErrBadInterval},
+		{key: "2024-01-01/PT1HT1M", err: ErrBadInterval},
+		{key: "2024-01-01T10:00/PT1.5H", exp: Interval{d(2024, 1, 1, 10, 0), d(2024, 1, 1, 11, 30)}},
+		{key: "some day", err: ErrBadInterval},
+		{key: "this 7 days", err: ErrBadInterval},
+		{key: "last 7 parrots", err: ErrBadUnit},
+	}
+	for _, st := range stages {
+		t.Run(st.key, func(t *testing.T) {
+			iv, err := ParseInterval(st.key, now, loc)
+			if err != st.err {
+				t.Fatalf("error mismatch: need %v, got %v", st.err, err)
+			}
+			if err == nil && (!iv.Start.Equal(st.exp.Start) || !iv.End.Equal(st.exp.End)) {
+				t.Errorf("interval mismatch: need %s, got %s", st.exp, iv)
+			}
+		})
+	}
+}
+
+func TestInterval(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	iv := func(a, b int) Interval {
+		return Interval{base.Add(time.Duration(a) * time.Hour), base.Add(time.Duration(b) * time.Hour)}
+	}
+	t.Run("contains", func(t *testing.T) {
+		x := iv(1, 3)
+		if !x.Contains(base.Add(time.Hour)) || x.Contains(base.Add(3*time.Hour)) || x.Contains(base) {
+			t.Error("contains fail")
+		}
+	})
+	t.Run("overlaps", func(t *testing.T) {
+		if !iv(1, 3).Overlaps(iv(2, 4)) || iv(1, 3).Overlaps(iv(3, 4)) {
+			t.Error("overlaps fail")
+		}
+	})
+	t.Run("intersect", func(t *testing.T) {
+		if r, ok := iv(1, 3).Intersect(iv(2, 4)); !ok || r != iv(2, 3) {
+			t.Errorf("intersect fail: %s", r)
+		}
+		if _, ok := iv(1, 2).Intersect(iv(2, 4)); ok {
+			t.Error("intersect of adjacent intervals must be empty")
+		}
+	})
+	t.Run("union", func(t *testing.T) {
+		if r, ok := iv(1, 2).Union(iv(2, 4)); !ok || r != iv(1, 4) {
+			t.Errorf("union fail: %s", r)
+		}
+		if _, ok := iv(1, 2).Union(iv(3, 4)); ok {
+			t.Error("union of disjoint intervals must fail")
+		}
+	})
+	t.Run("split", func(t *testing.T) {
+		r := iv(0, 5).Split(2 * time.Hour)
+		if len(r) != 3 || r[0] != iv(0, 2) || r[2] != iv(4, 5) {
+			t.Errorf("split fail: %v", r)
+		}
+		if r = iv(0, 5).Split(time.Second); len(r) != 5*3600 || cap(r) > 2*len(r) {
+			t.Errorf("split fail: %d items, cap %d", len(r), cap(r))
+		}
+	})
+}
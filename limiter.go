@@ -0,0 +1,225 @@
+package clock
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// limiterAlg is a rate limiting algorithm. Methods are called under limiter lock.
+type limiterAlg interface {
+	// reserve takes n events at time now (ns) if they fit into maxWait and returns wait duration.
+	reserve(now int64, n int, maxWait int64) (wait int64, ok bool)
+	// cancel returns n reserved events.
+	cancel(n int)
+}
+
+// Limiter is a rate limiter driven by clock Interface, so it may be tested by controllable clock (Stuck, Jump).
+// See NewTokenBucket, NewLeakyBucket and NewGCRA.
+type Limiter struct {
+	clock Interface
+	mux   sync.Mutex
+	alg   limiterAlg
+}
+
+// Reservation describes reserved events.
+type Reservation struct {
+	// False means events can't be reserved.
+	OK bool
+	// Delay before the events may happen.
+	Delay time.Duration
+
+	lim *Limiter
+	n   int
+}
+
+// Cancel returns reserved events to the limiter, e.g. if reservation's owner gave up waiting.
+func (r Reservation) Cancel() {
+	if r.OK && r.lim != nil {
+		r.lim.mux.Lock()
+		r.lim.alg.cancel(r.n)
+		r.lim.mux.Unlock()
+	}
+}
+
+func newLimiter(clock Interface, alg limiterAlg) *Limiter {
+	if clock == nil {
+		clock = Native{}
+	}
+	return &Limiter{clock: clock, alg: alg}
+}
+
+// Allow reports whether an event may happen now.
+func (l *Limiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now.
+func (l *Limiter) AllowN(n int) bool {
+	_, ok := l.reserve(n, 0)
+	return ok
+}
+
+// Reserve reserves an event. Caller must wait reservation Delay before the event or Cancel it.
+func (l *Limiter) Reserve() Reservation {
+	return l.ReserveN(1)
+}
+
+// ReserveN reserves n events.
+func (l *Limiter) ReserveN(n int) Reservation {
+	wait, ok := l.reserve(n, math.MaxInt64)
+	return Reservation{OK: ok, Delay: time.Duration(wait), lim: l, n: n}
+}
+
+// Wait blocks until an event may happen or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen or ctx is done. Waiting beyond ctx deadline fails immediately with
+// ErrLimitExceeded. Waiting uses clock timers if clock implements TimerInterface.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	maxWait := int64(math.MaxInt64)
+	if d, ok := ctx.Deadline(); ok {
+		maxWait = int64(d.Sub(l.clock.Now()))
+	}
+	wait, ok := l.reserve(n, maxWait)
+	if !ok {
+		return ErrLimitExceeded
+	}
+	if wait <= 0 {
+		return nil
+	}
+	ch := make(chan struct{})
+	t := afterFunc(l.clock, time.Duration(wait), func() { close(ch) })
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		if t.Stop() {
+			Reservation{OK: true, lim: l, n: n}.Cancel()
+		}
+		return ctx.Err()
+	}
+}
+
+func (l *Limiter) reserve(n int, maxWait int64) (int64, bool) {
+	now := l.clock.Now().UnixNano()
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.alg.reserve(now, n, maxWait)
+}
+
+// tokenBucket holds up to burst tokens refilled at rate tokens per nanosecond.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   int64
+}
+
+// NewTokenBucket makes token bucket limiter: bucket holds up to burst tokens and refills at rate tokens per second.
+// Bursts up to burst events are allowed, the long-term rate is limited by rate.
+func NewTokenBucket(clock Interface, rate float64, burst int) *Limiter {
+	return newLimiter(clock, &tokenBucket{rate: rate / 1e9, burst: float64(burst), tokens: float64(burst), last: -1})
+}
+
+func (b *tokenBucket) reserve(now int64, n int, maxWait int64) (int64, bool) {
+	if b.last == -1 {
+		b.last = now
+	}
+	if now > b.last {
+		b.tokens = math.Min(b.burst, b.tokens+float64(now-b.last)*b.rate)
+		b.last = now
+	}
+	if float64(n) > b.burst {
+		return 0, false
+	}
+	var wait int64
+	if rest := b.tokens - float64(n); rest < 0 {
+		if b.rate <= 0 {
+			return 0, false
+		}
+		wait = int64(math.Ceil(-rest / b.rate))
+	}
+	if wait > maxWait {
+		return 0, false
+	}
+	b.tokens -= float64(n)
+	return wait, true
+}
+
+func (b *tokenBucket) cancel(n int) {
+	b.tokens = math.Min(b.burst, b.tokens+float64(n))
+}
+
+// tatAlg is a theoretical arrival time based algorithm: events are spaced by interval; tolerance allows events
+// ahead of schedule (bursts for GCRA, queue for leaky bucket).
+type tatAlg struct {
+	interval  int64
+	tolerance int64
+	// Theoretical arrival time of the next event.
+	tat int64
+	// Queue mode: allowed events wait their turn (leaky bucket as queue).
+	queue bool
+}
+
+// NewLeakyBucket makes leaky bucket limiter (as a queue): events leak at constant rate per second without bursts,
+// up to capacity events may wait in the queue. Allow succeeds only if queue is empty, Reserve and Wait queue events.
+// Panics if rate isn't in (0, 1e9].
+func NewLeakyBucket(clock Interface, rate float64, capacity int) *Limiter {
+	if !(rate > 0 && rate <= 1e9) {
+		panic("clock: leaky bucket rate must be in (0, 1e9]")
+	}
+	interval := int64(1e9 / rate)
+	return newLimiter(clock, &tatAlg{interval: interval, tolerance: interval * int64(capacity), queue: true})
+}
+
+// NewGCRA makes generic cell rate algorithm limiter: up to limit events per period with bursts up to burst events.
+// GCRA is equivalent to sliding window of period, but keeps only one timestamp.
+// Panics if limit or period isn't positive or limit exceeds period in nanoseconds.
+func NewGCRA(clock Interface, limit int, period time.Duration, burst int) *Limiter {
+	if limit <= 0 || period <= 0 || int64(limit) > int64(period) {
+		panic("clock: GCRA limit and period must be positive and limit must not exceed period in nanoseconds")
+	}
+	interval := int64(period) / int64(limit)
+	if burst < 1 {
+		burst = 1
+	}
+	return newLimiter(clock, &tatAlg{interval: interval, tolerance: interval * int64(burst-1)})
+}
+
+func (a *tatAlg) reserve(now int64, n int, maxWait int64) (int64, bool) {
+	tat := a.tat
+	if tat < now {
+		tat = now
+	}
+	var wait int64
+	if a.queue {
+		// Events wait their turn; tolerance limits the queue length.
+		if wait = tat - now; wait+int64(n-1)*a.interval > a.tolerance {
+			return 0, false
+		}
+	} else {
+		if int64(n-1)*a.interval > a.tolerance {
+			return 0, false
+		}
+		// The last of n events may happen tolerance ahead of its theoretical time.
+		if allowAt := tat + int64(n-1)*a.interval - a.tolerance; allowAt > now {
+			wait = allowAt - now
+		}
+	}
+	if wait > maxWait {
+		return 0, false
+	}
+	a.tat = tat + int64(n)*a.interval
+	return wait, true
+}
+
+func (a *tatAlg) cancel(n int) {
+	a.tat -= int64(n) * a.interval
+}
@@ -0,0 +1,117 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// KeyedLimiter is a set of limiters by key, e.g. per client. Keys idle for longer than idle period are evicted;
+// evicted key starts over with a fresh limiter, so idle period should exceed limiter's refill time.
+type KeyedLimiter struct {
+	clock Interface
+	idle  time.Duration
+	fn    func(clock Interface) *Limiter
+
+	mux sync.Mutex
+	m   map[string]*keyedLimiter
+	// Eviction job handle.
+	evict Timer
+}
+
+type keyedLimiter struct {
+	*Limiter
+	// Last access time (ns).
+	last int64
+}
+
+// NewKeyedLimiter makes keyed limiter with limiters made by fn, e.g.
+//
+//	NewKeyedLimiter(c, time.Minute, func(c Interface) *Limiter { return NewTokenBucket(c, 10, 20) })
+//
+// If clock implements Scheduler (Clock), idle keys are evicted by scheduled job every idle period until Close.
+// Otherwise, call Evict periodically.
+func NewKeyedLimiter(clock Interface, idle time.Duration, fn func(clock Interface) *Limiter) *KeyedLimiter {
+	if clock == nil {
+		clock = Native{}
+	}
+	k := &KeyedLimiter{
+		clock: clock,
+		idle:  idle,
+		fn:    fn,
+		m:     make(map[string]*keyedLimiter),
+	}
+	if s, ok := clock.(Scheduler); ok && idle > 0 {
+		k.evict = s.Every(idle, k.Evict)
+	}
+	return k
+}
+
+// Close unregisters eviction job.
+func (k *KeyedLimiter) Close() {
+	if k.evict != nil {
+		k.evict.Stop()
+	}
+}
+
+// Allow reports whether an event of key may happen now.
+func (k *KeyedLimiter) Allow(key string) bool {
+	return k.get(key).AllowN(1)
+}
+
+// AllowN reports whether n events of key may happen now.
+func (k *KeyedLimiter) AllowN(key string, n int) bool {
+	return k.get(key).AllowN(n)
+}
+
+// Reserve reserves an event of key.
+func (k *KeyedLimiter) Reserve(key string) Reservation {
+	return k.get(key).ReserveN(1)
+}
+
+// ReserveN reserves n events of key.
+func (k *KeyedLimiter) ReserveN(key string, n int) Reservation {
+	return k.get(key).ReserveN(n)
+}
+
+// Wait blocks until an event of key may happen or ctx is done.
+func (k *KeyedLimiter) Wait(ctx context.Context, key string) error {
+	return k.get(key).WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events of key may happen or ctx is done.
+func (k *KeyedLimiter) WaitN(ctx context.Context, key string, n int) error {
+	return k.get(key).WaitN(ctx, n)
+}
+
+// Len returns number of tracked keys.
+func (k *KeyedLimiter) Len() int {
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	return len(k.m)
+}
+
+// Evict drops keys idle for longer than idle period.
+func (k *KeyedLimiter) Evict() {
+	now := k.clock.Now().UnixNano()
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	for key, l := range k.m {
+		if now-l.last >= int64(k.idle) {
+			delete(k.m, key)
+		}
+	}
+}
+
+func (k *KeyedLimiter) get(key string) *Limiter {
+	now := k.clock.Now().UnixNano()
+	k.mux.Lock()
+	defer k.mux.Unlock()
+	l, ok := k.m[key]
+	if !ok {
+		l = &keyedLimiter{Limiter: k.fn(k.clock)}
+		k.m[key] = l
+	}
+	l.last = now
+	return l.Limiter
+}
@@ -0,0 +1,181 @@
+package clock
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("token bucket", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		l := NewTokenBucket(c, 10, 5)
+		if !l.AllowN(5) {
+			t.Fatal("burst must be allowed")
+		}
+		if l.Allow() {
+			t.Fatal("empty bucket must deny")
+		}
+		c.Jump(100 * time.Millisecond)
+		if !l.Allow() || l.Allow() {
+			t.Fatal("bucket must refill one token per 100ms")
+		}
+		if r := l.Reserve(); !r.OK || r.Delay != 100*time.Millisecond {
+			t.Errorf("reservation mismatch: %+v", r)
+		} else {
+			r.Cancel()
+		}
+		c.Jump(time.Hour)
+		if !l.AllowN(5) || l.Allow() {
+			t.Error("bucket must hold up to burst tokens")
+		}
+		if l.AllowN(6) || l.ReserveN(6).OK {
+			t.Error("n above burst must be denied")
+		}
+	})
+	t.Run("leaky bucket", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		l := NewLeakyBucket(c, 10, 2)
+		if !l.Allow() || l.Allow() {
+			t.Fatal("leaky bucket must deny bursts")
+		}
+		for i, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond} {
+			if r := l.Reserve(); !r.OK || r.Delay != want {
+				t.Errorf("#%d reservation mismatch: %+v", i, r)
+			}
+		}
+		if r := l.Reserve(); r.OK {
+			t.Error("full queue must deny")
+		}
+		c.Jump(300 * time.Millisecond)
+		if !l.Allow() {
+			t.Error("drained queue must allow")
+		}
+	})
+	t.Run("gcra", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		l := NewGCRA(c, 10, time.Second, 3)
+		if !l.AllowN(3) || l.Allow() {
+			t.Fatal("burst mismatch")
+		}
+		c.Jump(100 * time.Millisecond)
+		if !l.Allow() || l.Allow() {
+			t.Fatal("rate mismatch")
+		}
+		if r := l.Reserve(); !r.OK || r.Delay != 100*time.Millisecond {
+			t.Errorf("reservation mismatch: %+v", r)
+		}
+		// Sliding window: no more than limit+burst-1 events per any period.
+		c.Jump(time.Hour)
+		var n int
+		for i := 0; i < 100; i++ {
+			if l.Allow() {
+				n++
+			}
+			c.Jump(10 * time.Millisecond)
+		}
+		if n != 12 {
+			t.Errorf("events per second mismatch: %d", n)
+		}
+	})
+	t.Run("wait", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		l := NewTokenBucket(c, 1, 1)
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan error)
+		go func() { done <- l.Wait(context.Background()) }()
+		time.Sleep(5 * time.Millisecond)
+		select {
+		case <-done:
+			t.Fatal("wait must block until clock advances")
+		default:
+		}
+		c.Jump(time.Second)
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("wait must finish after clock advance")
+		}
+		ctx, cancel := WithTimeout(context.Background(), c, 500*time.Millisecond)
+		defer cancel()
+		if err := l.Wait(ctx); err != ErrLimitExceeded {
+			t.Errorf("wait beyond deadline must fail, got %v", err)
+		}
+		ctx, cancel = WithTimeout(context.Background(), c, 2*time.Second)
+		go func() { done <- l.Wait(ctx) }()
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("cancelled wait mismatch: %v", err)
+		}
+		c.Jump(time.Second)
+		if !l.Allow() {
+			t.Error("cancelled wait must return token")
+		}
+	})
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	c := NewClock()
+	c.SetRate(0)
+	k := NewKeyedLimiter(c, time.Minute, func(c Interface) *Limiter { return NewTokenBucket(c, 1, 1) })
+	if !k.Allow("a") || k.Allow("a") || !k.Allow("b") {
+		t.Fatal("keys must have own limiters")
+	}
+	c.Jump(30 * time.Second)
+	_ = k.Allow("b")
+	c.Jump(31 * time.Second)
+	if n := k.Len(); n != 1 {
+		t.Errorf("idle key must be evicted, %d keys left", n)
+	}
+	c.Jump(2 * time.Minute)
+	if n := k.Len(); n != 0 {
+		t.Errorf("idle key must be evicted, %d keys left", n)
+	}
+	k.Close()
+	if n := c.Stats().Jobs; n != 0 {
+		t.Errorf("close must unregister eviction job: %d jobs", n)
+	}
+}
+
+func TestLimiterConfig(t *testing.T) {
+	c := NewClock()
+	for name, fn := range map[string]func(){
+		"gcra zero limit":       func() { NewGCRA(c, 0, time.Second, 1) },
+		"gcra zero period":      func() { NewGCRA(c, 10, 0, 1) },
+		"leaky bucket zero":     func() { NewLeakyBucket(c, 0, 1) },
+		"leaky bucket nan":      func() { NewLeakyBucket(c, math.NaN(), 1) },
+		"leaky bucket -inf":     func() { NewLeakyBucket(c, math.Inf(-1), 1) },
+		"leaky bucket too fast": func() { NewLeakyBucket(c, 1e10, 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: bad config must panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func BenchmarkLimiter(b *testing.B) {
+	c := NewClock()
+	c.Start()
+	defer c.Stop()
+	l := NewTokenBucket(c, 1e9, 1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = l.Allow()
+	}
+}
@@ -0,0 +1,217 @@
+package clock
+
+import (
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Lamport is a lock-free Lamport logical clock.
+type Lamport struct {
+	n uint64
+}
+
+// Now returns current value of the clock.
+func (l *Lamport) Now() uint64 {
+	return atomic.LoadUint64(&l.n)
+}
+
+// Tick increments the clock on local or send event and returns new value.
+func (l *Lamport) Tick() uint64 {
+	return atomic.AddUint64(&l.n, 1)
+}
+
+// Witness merges remote value on receive event and returns new value: max(local, remote) + 1.
+func (l *Lamport) Witness(remote uint64) uint64 {
+	for {
+		n := atomic.LoadUint64(&l.n)
+		next := n
+		if remote > next {
+			next = remote
+		}
+		next++
+		if atomic.CompareAndSwapUint64(&l.n, n, next) {
+			return next
+		}
+	}
+}
+
+// Order is a causal order of two vector clocks.
+type Order int8
+
+const (
+	OrderConcurrent Order = iota
+	OrderBefore
+	OrderEqual
+	OrderAfter
+)
+
+func (o Order) String() string {
+	switch o {
+	case OrderBefore:
+		return "before"
+	case OrderEqual:
+		return "equal"
+	case OrderAfter:
+		return "after"
+	}
+	return "concurrent"
+}
+
+// Vector is a vector clock: map of node IDs to their counters. Missing node means zero counter.
+// Vector isn't thread-safe.
+type Vector map[string]uint64
+
+// Increment increments counter of node and returns new value.
+func (v Vector) Increment(node string) uint64 {
+	v[node]++
+	return v[node]
+}
+
+// Get returns counter of node.
+func (v Vector) Get(node string) uint64 {
+	return v[node]
+}
+
+// Merge merges other vector into v taking the max of each counter.
+func (v Vector) Merge(other Vector) Vector {
+	for node, n := range other {
+		if n > v[node] {
+			v[node] = n
+		}
+	}
+	return v
+}
+
+// Compare returns causal order of v relative to other.
+func (v Vector) Compare(other Vector) Order {
+	var less, greater bool
+	for node, n := range v {
+		if m := other[node]; n < m {
+			less = true
+		} else if n > m {
+			greater = true
+		}
+	}
+	for node, m := range other {
+		if _, ok := v[node]; !ok && m > 0 {
+			less = true
+		}
+	}
+	switch {
+	case less && greater:
+		return OrderConcurrent
+	case less:
+		return OrderBefore
+	case greater:
+		return OrderAfter
+	}
+	return OrderEqual
+}
+
+// Clone returns copy of v.
+func (v Vector) Clone() Vector {
+	c := make(Vector, len(v))
+	for node, n := range v {
+		c[node] = n
+	}
+	return c
+}
+
+// AppendBinary appends binary encoding of v to dst: uvarint count of nodes followed by (uvarint length of node ID,
+// node ID, uvarint counter) records sorted by node ID. Zero counters are omitted.
+func (v Vector) AppendBinary(dst []byte) []byte {
+	nodes := v.nodes()
+	dst = appendUvarint(dst, uint64(len(nodes)))
+	for _, node := range nodes {
+		dst = appendUvarint(dst, uint64(len(node)))
+		dst = append(dst, node...)
+		dst = appendUvarint(dst, v[node])
+	}
+	return dst
+}
+
+// AppendText appends text encoding of v to dst: comma-separated "node:counter" pairs sorted by node ID, e.g.
+// "a:1,b:3". Zero counters are omitted.
+func (v Vector) AppendText(dst []byte) []byte {
+	for i, node := range v.nodes() {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, node...)
+		dst = append(dst, ':')
+		dst = strconv.AppendUint(dst, v[node], 10)
+	}
+	return dst
+}
+
+func (v Vector) String() string {
+	return string(v.AppendText(nil))
+}
+
+func (v Vector) nodes() []string {
+	nodes := make([]string, 0, len(v))
+	for node, n := range v {
+		if n > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// DecodeVector decodes vector encoded by AppendBinary.
+func DecodeVector(p []byte) (Vector, error) {
+	cnt, i := binary.Uvarint(p)
+	if i <= 0 || cnt > uint64(len(p)) {
+		return nil, ErrBadVector
+	}
+	p = p[i:]
+	v := make(Vector, cnt)
+	for ; cnt > 0; cnt-- {
+		l, i := binary.Uvarint(p)
+		if i <= 0 || l > uint64(len(p)-i) {
+			return nil, ErrBadVector
+		}
+		node := string(p[i : i+int(l)])
+		p = p[i+int(l):]
+		n, i := binary.Uvarint(p)
+		if i <= 0 {
+			return nil, ErrBadVector
+		}
+		p = p[i:]
+		v[node] = n
+	}
+	if len(p) > 0 {
+		return nil, ErrBadVector
+	}
+	return v, nil
+}
+
+// ParseVector parses vector encoded by AppendText.
+func ParseVector(raw string) (Vector, error) {
+	v := make(Vector)
+	if len(raw) == 0 {
+		return v, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		i := strings.LastIndexByte(pair, ':')
+		if i <= 0 {
+			return nil, ErrBadVector
+		}
+		n, err := strconv.ParseUint(pair[i+1:], 10, 64)
+		if err != nil {
+			return nil, ErrBadVector
+		}
+		v[pair[:i]] = n
+	}
+	return v, nil
+}
+
+func appendUvarint(dst []byte, x uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	return append(dst, buf[:n]...)
+}
@@ -0,0 +1,87 @@
+package clock
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/koykov/x2bytes"
+)
+
+func TestLamport(t *testing.T) {
+	var l Lamport
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				l.Tick()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := l.Now(); n != 4000 {
+		t.Errorf("counter mismatch: %d", n)
+	}
+	if n := l.Witness(10); n != 4001 {
+		t.Errorf("witness of past value mismatch: %d", n)
+	}
+	if n := l.Witness(5000); n != 5001 {
+		t.Errorf("witness of future value mismatch: %d", n)
+	}
+}
+
+func TestVector(t *testing.T) {
+	t.Run("order", func(t *testing.T) {
+		a, b := Vector{}, Vector{}
+		a.Increment("a")
+		b.Merge(a).Increment("b")
+		if o := a.Compare(b); o != OrderBefore {
+			t.Errorf("order mismatch: %s", o)
+		}
+		if o := b.Compare(a); o != OrderAfter {
+			t.Errorf("order mismatch: %s", o)
+		}
+		c := a.Clone()
+		c.Increment("c")
+		if o := c.Compare(b); o != OrderConcurrent {
+			t.Errorf("order mismatch: %s", o)
+		}
+		if o := (Vector{"a": 1, "z": 0}).Compare(a); o != OrderEqual {
+			t.Errorf("order mismatch: %s", o)
+		}
+		b.Merge(c)
+		if b.Get("a") != 1 || b.Get("b") != 1 || b.Get("c") != 1 {
+			t.Errorf("merge mismatch: %s", b)
+		}
+		if a.Get("c") != 0 {
+			t.Error("clone must not affect origin")
+		}
+	})
+	t.Run("encoding", func(t *testing.T) {
+		v := Vector{"node-2": 300, "node-1": 1, "idle": 0}
+		if s := v.String(); s != "node-1:1,node-2:300" {
+			t.Errorf("text mismatch: %s", s)
+		}
+		x, err := ParseVector(v.String())
+		if err != nil || x.Compare(v) != OrderEqual {
+			t.Errorf("parse mismatch: %s %v", x, err)
+		}
+		p := v.AppendBinary(nil)
+		if x, err = DecodeVector(p); err != nil || x.Compare(v) != OrderEqual {
+			t.Errorf("decode mismatch: %s %v", x, err)
+		}
+		if _, err = DecodeVector(p[:len(p)-1]); err != ErrBadVector {
+			t.Errorf("decode error expected, got %v", err)
+		}
+		if _, err = ParseVector("a:1,b"); err != ErrBadVector {
+			t.Errorf("parse error expected, got %v", err)
+		}
+		if b, _ := x2bytes.ToBytes(nil, v); string(b) != "node-1:1,node-2:300" {
+			t.Errorf("x2bytes mismatch: %s", b)
+		}
+		if b, _ := x2bytes.ToBytes(nil, MakeHLCTimestamp(10, 2)); string(b) != "10.2" {
+			t.Errorf("x2bytes mismatch: %s", b)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package clock
+
+import (
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// SchedMetrics is an interface of scheduler metrics. Methods are called by scheduler and must be thread-safe.
+type SchedMetrics interface {
+	// JobRun registers finished run of a job: lag of run start relative to its scheduled time and run duration.
+	JobRun(lag, dur time.Duration)
+	// JobPanic registers panic of a job.
+	JobPanic(v any)
+	// TickSkipped registers tick which didn't check jobs because previous check was still running.
+	TickSkipped()
+}
+
+// JobInfo describes a run of a job for hooks.
+type JobInfo struct {
+	// Job ID, unique within a clock.
+	ID uint64
+	// Scheduled time of the run.
+	Scheduled time.Time
+	// Lag of run start relative to scheduled time, includes time spent by previous jobs of the tick.
+	Lag time.Duration
+}
+
+// Stats is a snapshot of clock statistics.
+type Stats struct {
+	// Count of clock updates.
+	Ticks uint64
+	// Count of Read() calls.
+	Reads uint64
+	// Count of detected backward steps.
+	BackwardSteps uint64
+	// Count of active jobs (including pending timers).
+	Jobs int
+	// Count of job runs, job panics and ticks skipped by scheduler.
+	Runs, Panics, SkippedTicks uint64
+	// Observed jitter of ticker goroutine: mean and max deviation of tick intervals from tick period.
+	JitterMean, JitterMax time.Duration
+}
+
+// Stats returns snapshot of clock statistics.
+func (c *Clock) Stats() Stats {
+	st := Stats{
+		Ticks:         c.Ticks(),
+		Reads:         c.Reads(),
+		BackwardSteps: c.BackwardSteps(),
+		JitterMax:     time.Duration(atomic.LoadInt64(&c.jitterMax)),
+	}
+	if n := atomic.LoadInt64(&c.jitterN); n > 0 {
+		st.JitterMean = time.Duration(atomic.LoadInt64(&c.jitterSum) / n)
+	}
+	c.tmux.Lock()
+	s := c.sched
+	c.tmux.Unlock()
+	if s != nil {
+		st.Jobs = s.count()
+		st.Runs = atomic.LoadUint64(&s.runs)
+		st.Panics = atomic.LoadUint64(&s.panics)
+		st.SkippedTicks = atomic.LoadUint64(&s.skipped)
+	}
+	return st
+}
+
+// observeTick registers interval between ticks of ticker with given period.
+func (c *Clock) observeTick(interval, period time.Duration) {
+	j := int64(interval - period)
+	if j < 0 {
+		j = -j
+	}
+	atomic.AddInt64(&c.jitterSum, j)
+	atomic.AddInt64(&c.jitterN, 1)
+	for {
+		max := atomic.LoadInt64(&c.jitterMax)
+		if j <= max || atomic.CompareAndSwapInt64(&c.jitterMax, max, j) {
+			return
+		}
+	}
+}
+
+// ExpvarMetrics is an implementation of SchedMetrics publishing metrics via expvar:
+//
+//	{"runs": 10, "panics": 0, "skipped_ticks": 1, "lag": {...}, "duration": {...}}
+//
+// Lag and duration are histograms with cumulative exponential buckets from 1µs to ~17m (le_<ns> keys) plus count and
+// sum in nanoseconds.
+type ExpvarMetrics struct {
+	runs, panics, skipped expvar.Int
+	lag, dur              Histogram
+}
+
+// NewExpvarMetrics makes metrics published as expvar map with given name. Like expvar.NewMap, it panics if name is
+// already registered.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{}
+	v := expvar.NewMap(name)
+	v.Set("runs", &m.runs)
+	v.Set("panics", &m.panics)
+	v.Set("skipped_ticks", &m.skipped)
+	v.Set("lag", &m.lag)
+	v.Set("duration", &m.dur)
+	return m
+}
+
+func (m *ExpvarMetrics) JobRun(lag, dur time.Duration) {
+	m.runs.Add(1)
+	m.lag.Observe(lag)
+	m.dur.Observe(dur)
+}
+
+func (m *ExpvarMetrics) JobPanic(any) {
+	m.panics.Add(1)
+}
+
+func (m *ExpvarMetrics) TickSkipped() {
+	m.skipped.Add(1)
+}
+
+// histBuckets is a count of exponential (x4) histogram buckets starting from 1µs, the last one is ~17m.
+const histBuckets = 16
+
+// Histogram is a lock-free histogram of durations implementing expvar.Var.
+type Histogram struct {
+	count, sum uint64
+	buckets    [histBuckets + 1]uint64
+}
+
+// Observe registers duration.
+func (h *Histogram) Observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, uint64(d))
+	i, bound := 0, time.Microsecond
+	for ; i < histBuckets && d > bound; i++ {
+		bound *= 4
+	}
+	atomic.AddUint64(&h.buckets[i], 1)
+}
+
+// Count returns count of observed durations.
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+// String returns JSON representation of histogram.
+func (h *Histogram) String() string {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '{')
+	var cum uint64
+	bound := time.Microsecond
+	for i := 0; i < histBuckets; i++ {
+		cum += atomic.LoadUint64(&h.buckets[i])
+		buf = append(buf, `"le_`...)
+		buf = strconv.AppendInt(buf, int64(bound), 10)
+		buf = append(buf, `":`...)
+		buf = strconv.AppendUint(buf, cum, 10)
+		buf = append(buf, ',')
+		bound *= 4
+	}
+	buf = append(buf, `"count":`...)
+	buf = strconv.AppendUint(buf, atomic.LoadUint64(&h.count), 10)
+	buf = append(buf, `,"sum":`...)
+	buf = strconv.AppendUint(buf, atomic.LoadUint64(&h.sum), 10)
+	buf = append(buf, '}')
+	return string(buf)
+}
+
+var _ SchedMetrics = (*ExpvarMetrics)(nil)
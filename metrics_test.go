@@ -0,0 +1,136 @@
+package clock
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testMetrics struct {
+	// Atomic 64-bit field goes first to keep alignment on 32-bit platforms.
+	lag                   int64
+	runs, panics, skipped uint32
+}
+
+func (m *testMetrics) JobRun(lag, _ time.Duration) {
+	atomic.AddUint32(&m.runs, 1)
+	atomic.StoreInt64(&m.lag, int64(lag))
+}
+
+func (m *testMetrics) JobPanic(any) { atomic.AddUint32(&m.panics, 1) }
+
+func (m *testMetrics) TickSkipped() { atomic.AddUint32(&m.skipped, 1) }
+
+func TestMetrics(t *testing.T) {
+	t.Run("hooks", func(t *testing.T) {
+		var (
+			m      testMetrics
+			before []JobInfo
+			after  []error
+		)
+		c := NewClock()
+		c.SetRate(0)
+		c.Metrics = &m
+		c.BeforeRun = func(info JobInfo) { before = append(before, info) }
+		c.AfterRun = func(_ JobInfo, _ time.Duration, err error) { after = append(after, err) }
+		start := c.Now()
+		c.Schedule(time.Minute, func() {})
+		c.Jump(90 * time.Second)
+		if len(before) != 1 || len(after) != 1 || after[0] != nil {
+			t.Fatalf("hooks must be called once: %d before, %d after", len(before), len(after))
+		}
+		if info := before[0]; info.ID == 0 || !info.Scheduled.Equal(start.Add(time.Minute)) || info.Lag < 30*time.Second ||
+			info.Lag > 30*time.Second+time.Second {
+			t.Errorf("wrong job info: %+v", info)
+		}
+		if m.runs != 1 || time.Duration(m.lag) != before[0].Lag {
+			t.Errorf("wrong metrics: %d runs, lag %s", m.runs, time.Duration(m.lag))
+		}
+	})
+	t.Run("batch lag", func(t *testing.T) {
+		var lags []time.Duration
+		c := NewClock()
+		c.SetRate(0)
+		c.BeforeRun = func(info JobInfo) { lags = append(lags, info.Lag) }
+		c.Schedule(time.Minute, func() { time.Sleep(5 * time.Millisecond) })
+		c.Schedule(time.Minute, func() {})
+		c.Jump(time.Minute + time.Second)
+		if len(lags) != 2 || lags[0] < time.Second || lags[1] < lags[0]+5*time.Millisecond {
+			t.Errorf("lag must account previous jobs of the tick: %v", lags)
+		}
+	})
+	t.Run("panic", func(t *testing.T) {
+		var (
+			m   testMetrics
+			rec []any
+			err error
+		)
+		c := NewClock()
+		c.SetRate(0)
+		c.Metrics = &m
+		c.OnPanic = func(v any) { rec = append(rec, v) }
+		c.AfterRun = func(_ JobInfo, _ time.Duration, e error) { err = e }
+		c.Schedule(time.Minute, func() { panic("oops") })
+		c.Jump(time.Minute + time.Second)
+		c.Jump(2 * time.Minute)
+		if len(rec) != 2 || rec[0] != "oops" || err != ErrJobPanic {
+			t.Errorf("panic must be recovered and job must keep running: %v, %v", rec, err)
+		}
+		st := c.Stats()
+		if st.Jobs != 1 || st.Runs != 2 || st.Panics != 2 || m.panics != 2 {
+			t.Errorf("wrong stats: %+v", st)
+		}
+	})
+	t.Run("stats", func(t *testing.T) {
+		c := NewClock()
+		c.Precision = time.Millisecond
+		c.Start()
+		time.Sleep(50 * time.Millisecond)
+		c.Stop()
+		st := c.Stats()
+		if st.Ticks == 0 || st.JitterMax == 0 || st.JitterMean > st.JitterMax {
+			t.Errorf("wrong tick stats: %+v", st)
+		}
+	})
+	t.Run("expvar", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		// Unique name allows to run test many times (-count=N).
+		name := "clock_test_sched_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		c.Metrics = NewExpvarMetrics(name)
+		c.Schedule(time.Minute, func() {})
+		c.Jump(time.Minute + time.Millisecond)
+		var out struct {
+			Runs uint64 `json:"runs"`
+			Lag  struct {
+				Count uint64 `json:"count"`
+				Sum   uint64 `json:"sum"`
+			} `json:"lag"`
+			Duration map[string]uint64 `json:"duration"`
+		}
+		if err := json.Unmarshal([]byte(expvar.Get(name).String()), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Runs != 1 || out.Lag.Count != 1 || out.Lag.Sum < uint64(time.Millisecond) || out.Duration["count"] != 1 {
+			t.Errorf("wrong expvar output: %+v", out)
+		}
+	})
+}
+
+func TestHistogram(t *testing.T) {
+	var h Histogram
+	h.Observe(500 * time.Nanosecond)
+	h.Observe(3 * time.Microsecond)
+	h.Observe(10 * time.Minute)
+	h.Observe(time.Hour)
+	var out map[string]uint64
+	if err := json.Unmarshal([]byte(h.String()), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["le_1000"] != 1 || out["le_4000"] != 2 || out["le_1073741824000"] != 3 || out["count"] != 4 || h.Count() != 4 {
+		t.Errorf("wrong buckets: %v", out)
+	}
+}
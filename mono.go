@@ -0,0 +1,60 @@
+package clock
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// monoBase is a process-wide origin of monotonic readings.
+var monoBase = time.Now()
+
+// Monotonic represents source of monotonic readings.
+type Monotonic interface {
+	// Mono returns nanoseconds elapsed since process-wide origin. Readings never decrease and don't depend on
+	// wall clock steps.
+	Mono() int64
+}
+
+// monoNow returns current monotonic reading.
+func monoNow() int64 {
+	return int64(time.Since(monoBase))
+}
+
+// Mono returns current monotonic reading.
+func (c Native) Mono() int64 {
+	return monoNow()
+}
+
+// Stopwatch measures elapsed time using monotonic readings.
+type Stopwatch struct {
+	src        Monotonic
+	start, lap int64
+}
+
+// NewStopwatch makes and starts new stopwatch. Nil source means Native.
+func NewStopwatch(src Monotonic) *Stopwatch {
+	if src == nil {
+		src = Native{}
+	}
+	s := &Stopwatch{src: src}
+	s.Reset()
+	return s
+}
+
+// Reset restarts the stopwatch.
+func (s *Stopwatch) Reset() {
+	now := s.src.Mono()
+	atomic.StoreInt64(&s.start, now)
+	atomic.StoreInt64(&s.lap, now)
+}
+
+// Elapsed returns time elapsed since start or last reset.
+func (s *Stopwatch) Elapsed() time.Duration {
+	return time.Duration(s.src.Mono() - atomic.LoadInt64(&s.start))
+}
+
+// Lap returns time elapsed since previous lap (or start) and begins new lap.
+func (s *Stopwatch) Lap() time.Duration {
+	now := s.src.Mono()
+	return time.Duration(now - atomic.SwapInt64(&s.lap, now))
+}
@@ -1,108 +1,255 @@
 package clock
 
 import (
+	"math"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/koykov/bytealg"
 )
 
-func Relative(raw string) (dur time.Duration, err error) {
-	var (
-		neg bool
-		off int
-	)
+// SyntaxOptions describes how RelativeParser treats unit aliases.
+type SyntaxOptions uint8
+
+const (
+	// SyntaxLenient allows plural forms and trailing dots ("mins", "sec.") and comma separators.
+	SyntaxLenient SyntaxOptions = 1 << iota
+	// SyntaxCaseFold allows case-insensitive aliases. Exact match has priority, so "M" remains month.
+	SyntaxCaseFold
+	// SyntaxExact rejects calendar units (months, years, ...).
+	SyntaxExact
+
+	// SyntaxStrict allows only registered aliases.
+	SyntaxStrict SyntaxOptions = 0
+	// SyntaxDefault is a set of options used by Relative function.
+	SyntaxDefault = SyntaxLenient | SyntaxCaseFold
+)
+
+// RelativeLocale is a set of unit aliases.
+type RelativeLocale map[string]Unit
+
+var (
+	// RelativeLocaleEN contains builtin english aliases.
+	RelativeLocaleEN = RelativeLocale{
+		"nsec": Nanosecond, "ns": Nanosecond,
+		"usec": Microsecond, "us": Microsecond, "µs": Microsecond,
+		"msec": Millisecond, "ms": Millisecond,
+		"seconds": Second, "second": Second, "sec": Second, "s": Second,
+		"minutes": Minute, "minute": Minute, "min": Minute, "m": Minute,
+		"hours": Hour, "hour": Hour, "hr": Hour, "h": Hour,
+		"days": Day, "day": Day, "d": Day,
+		"weeks": Week, "week": Week, "w": Week,
+		"months": Month, "month": Month, "mo": Month, "M": Month,
+		"quarters": Quarter, "quarter": Quarter, "q": Quarter,
+		"years": Year, "year": Year, "y": Year,
+		"century": Century, "centuries": Century, "cen": Century, "c": Century,
+		"millennium": Millennium, "millennia": Millennium, "mil": Millennium,
+	}
+	// RelativeLocaleRU contains russian aliases.
+	RelativeLocaleRU = RelativeLocale{
+		"нс": Nanosecond, "наносекунда": Nanosecond, "наносекунды": Nanosecond, "наносекунд": Nanosecond,
+		"мкс": Microsecond, "микросекунда": Microsecond, "микросекунды": Microsecond, "микросекунд": Microsecond,
+		"мс": Millisecond, "миллисекунда": Millisecond, "миллисекунды": Millisecond, "миллисекунд": Millisecond,
+		"с": Second, "сек": Second, "секунда": Second, "секунду": Second, "секунды": Second, "секунд": Second,
+		"мин": Minute, "минута": Minute, "минуту": Minute, "минуты": Minute, "минут": Minute,
+		"ч": Hour, "час": Hour, "часа": Hour, "часов": Hour,
+		"д": Day, "дн": Day, "день": Day, "дня": Day, "дней": Day,
+		"нед": Week, "неделя": Week, "неделю": Week, "недели": Week, "недель": Week,
+		"мес": Month, "месяц": Month, "месяца": Month, "месяцев": Month,
+		"кв": Quarter, "квартал": Quarter, "квартала": Quarter, "кварталов": Quarter,
+		"г": Year, "год": Year, "года": Year, "лет": Year,
+		"век": Century, "века": Century, "веков": Century,
+		"тысячелетие": Millennium, "тысячелетия": Millennium, "тысячелетий": Millennium,
+	}
+)
+
+// RelativeParser parses relative spans like "2h 30min" using configurable unit vocabulary.
+type RelativeParser struct {
+	Options SyntaxOptions
+
+	mux    sync.RWMutex
+	alias  map[string]Unit
+	folded map[string]Unit
+}
+
+// DefaultRelativeParser is a parser used by Relative function.
+var DefaultRelativeParser = NewRelativeParser(SyntaxDefault)
+
+// NewRelativeParser makes new parser with builtin english aliases.
+func NewRelativeParser(opts SyntaxOptions) *RelativeParser {
+	p := &RelativeParser{Options: opts}
+	p.RegisterLocale(RelativeLocaleEN)
+	return p
+}
+
+// RegisterAlias registers custom alias of the unit.
+func (p *RelativeParser) RegisterAlias(alias string, unit Unit) *RelativeParser {
+	if len(alias) == 0 || unit == UnitUnknown {
+		return p
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if p.alias == nil {
+		p.alias = make(map[string]Unit)
+		p.folded = make(map[string]Unit)
+	}
+	p.alias[alias] = unit
+	// Lowercase aliases are preferable during case folding: "m" means minute, "M" means month.
+	if lower := strings.ToLower(alias); lower == alias {
+		p.folded[lower] = unit
+	} else if _, ok := p.folded[lower]; !ok {
+		p.folded[lower] = unit
+	}
+	return p
+}
+
+// RegisterLocale registers all aliases of the locale.
+func (p *RelativeParser) RegisterLocale(locale RelativeLocale) *RelativeParser {
+	for alias, unit := range locale {
+		p.RegisterAlias(alias, unit)
+	}
+	return p
+}
+
+// Parse parses raw span to duration.
+// Calendar units are converted using average duration, see Unit.Duration().
+func (p *RelativeParser) Parse(raw string) (dur time.Duration, err error) {
+	var neg, ovf bool
+	if neg, err = p.each(raw, func(n int64, unit Unit) {
+		d := unit.Duration()
+		// Saturated unit (millennium) doesn't fit duration at all.
+		if ovf = ovf || d == math.MaxInt64 || n > int64(math.MaxInt64/d) || dur > math.MaxInt64-time.Duration(n)*d; !ovf {
+			dur += time.Duration(n) * d
+		}
+	}); err != nil {
+		return 0, err
+	}
+	if ovf {
+		return 0, ErrBadNum
+	}
+	if neg {
+		dur = -dur
+	}
+	return
+}
+
+// each calls fn for each pair number/unit in raw span.
+func (p *RelativeParser) each(raw string, fn func(n int64, unit Unit)) (neg bool, err error) {
 	if raw = bytealg.TrimString(raw, " "); len(raw) == 0 {
 		err = ErrNoDur
 		return
 	}
+	var off int
 	if neg = raw[0] == '-'; neg {
 		off++
 	}
 	for off < len(raw) {
+		if off = p.relSkip(raw, off); off == len(raw) {
+			break
+		}
 		var (
-			n    time.Duration
-			unit string
+			n    int64
+			unit Unit
 			ok   bool
 		)
 		if n, off, ok = relNum(raw, off); !ok || n == 0 {
 			err = ErrBadNum
 			return
 		}
-		if unit, off = relUnit(raw, off); len(unit) == 0 {
+		var s string
+		if s, off = relUnit(raw, off); len(s) == 0 {
 			err = ErrBadUnit
 			return
 		}
-		switch unit {
-		case "nsec", "ns":
-			dur += n
-		case "usec", "us", "µs":
-			dur += n * time.Microsecond
-		case "msec", "ms":
-			dur += n * time.Millisecond
-		case "seconds", "second", "sec", "s":
-			dur += n * time.Second
-		case "minutes", "minute", "min", "m":
-			dur += n * time.Minute
-		case "hours", "hour", "hr", "h":
-			dur += n * time.Hour
-		case "days", "day", "d":
-			dur += n * 24 * time.Hour
-		case "weeks", "week", "w":
-			dur += n * 168 * time.Hour
-		case "months", "month", "M":
-			d := 24 * time.Hour
-			dur += n * (d*30 + d*44/100)
-		case "years", "year", "y":
-			d := 24 * time.Hour
-			dur += n * (d*365 + d*1/4)
-		case "century", "cen", "c":
-			d := 24 * time.Hour
-			dur += n * (d * 36525)
-		case "millennium", "mil":
-			d := 24 * time.Hour
-			dur += n * (d * 365250)
+		if unit = p.lookup(s); unit == UnitUnknown {
+			err = ErrBadUnit
+			return
 		}
-	}
-	if neg {
-		dur = -dur
+		if p.Options&SyntaxExact != 0 && unit.Calendar() {
+			err = ErrCalendarUnit
+			return
+		}
+		fn(n, unit)
 	}
 	return
 }
 
-func relNum(raw string, off int) (time.Duration, int, bool) {
+func (p *RelativeParser) lookup(s string) Unit {
+	if unit := p.lookup1(s); unit != UnitUnknown {
+		return unit
+	}
+	if p.Options&SyntaxLenient == 0 {
+		return UnitUnknown
+	}
+	if s1 := strings.TrimRight(s, "."); len(s1) > 0 && len(s1) < len(s) {
+		if unit := p.lookup1(s1); unit != UnitUnknown {
+			return unit
+		}
+		s = s1
+	}
+	if len(s) > 1 && (s[len(s)-1] == 's' || s[len(s)-1] == 'S') {
+		return p.lookup1(s[:len(s)-1])
+	}
+	return UnitUnknown
+}
+
+func (p *RelativeParser) lookup1(s string) Unit {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	if unit, ok := p.alias[s]; ok {
+		return unit
+	}
+	if p.Options&SyntaxCaseFold != 0 {
+		if unit, ok := p.folded[strings.ToLower(s)]; ok {
+			return unit
+		}
+	}
+	return UnitUnknown
+}
+
+func (p *RelativeParser) relSkip(raw string, off int) int {
+	for off < len(raw) {
+		c := raw[off]
+		if c == ' ' || c == '\t' || (c == ',' && p.Options&SyntaxLenient != 0) {
+			off++
+			continue
+		}
+		break
+	}
+	return off
+}
+
+// Relative parses raw span using default parser.
+func Relative(raw string) (time.Duration, error) {
+	return DefaultRelativeParser.Parse(raw)
+}
+
+func relNum(raw string, off int) (int64, int, bool) {
 	pos := off
-loop:
-	c := raw[pos]
-	if c == '0' || c == '1' || c == '2' || c == '3' || c == '4' || c == '5' || c == '6' || c == '7' || c == '8' || c == '9' {
+	for pos < len(raw) && raw[pos] >= '0' && raw[pos] <= '9' {
 		pos++
-		goto loop
 	}
 	if pos > off {
-		if i, err := strconv.Atoi(raw[off:pos]); err == nil {
-			return time.Duration(i), pos, true
+		if i, err := strconv.ParseInt(raw[off:pos], 10, 64); err == nil {
+			return i, pos, true
 		}
 	}
 	return 0, off, false
 }
 
 func relUnit(raw string, off int) (string, int) {
-	if raw[off] == ' ' {
+	for off < len(raw) && (raw[off] == ' ' || raw[off] == '\t') {
 		off++
 	}
 	pos := off
-loop:
-	if pos == len(raw)-1 {
-		pos++
-		return raw[off:pos], pos
-	}
-	c := raw[pos]
-	if c != '0' && c != '1' && c != '2' && c != '3' && c != '4' && c != '5' && c != '6' && c != '7' && c != '8' && c != '9' {
+	for pos < len(raw) {
+		c := raw[pos]
+		if (c >= '0' && c <= '9') || c == ' ' || c == '\t' || c == ',' || c == '-' || c == '+' {
+			break
+		}
 		pos++
-		goto loop
-	}
-	if raw[pos-1] == ' ' {
-		return raw[off : pos-1], pos
 	}
 	return raw[off:pos], pos
 }
@@ -0,0 +1,90 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelativeParser(t *testing.T) {
+	type stage struct {
+		key string
+		exp time.Duration
+		err error
+	}
+	run := func(t *testing.T, p *RelativeParser, stages []stage) {
+		for _, st := range stages {
+			t.Run(st.key, func(t *testing.T) {
+				dur, err := p.Parse(st.key)
+				if err != st.err {
+					t.Fatalf("error mismatch: need %v, got %v", st.err, err)
+				}
+				if err == nil && dur != st.exp {
+					t.Errorf("relative fail: need %s, got %s", st.exp, dur)
+				}
+			})
+		}
+	}
+	t.Run("strict", func(t *testing.T) {
+		run(t, NewRelativeParser(SyntaxStrict), []stage{
+			{key: "2h 30min", exp: 2*time.Hour + 30*time.Minute},
+			{key: "1M", exp: Month.Duration()},
+			{key: "1m", exp: time.Minute},
+			{key: "1mo", exp: Month.Duration()},
+			{key: "5mins", err: ErrBadUnit},
+			{key: "5H", err: ErrBadUnit},
+			{key: "5x 3h", err: ErrBadUnit},
+			{key: "3h 5x", err: ErrBadUnit},
+			{key: "3h, 5m", err: ErrBadNum},
+			{key: "h", err: ErrBadNum},
+			{key: "  ", err: ErrNoDur},
+		})
+	})
+	t.Run("lenient", func(t *testing.T) {
+		run(t, NewRelativeParser(SyntaxLenient), []stage{
+			{key: "5mins", exp: 5 * time.Minute},
+			{key: "5 min.", exp: 5 * time.Minute},
+			{key: "2 hrs, 5 secs", exp: 2*time.Hour + 5*time.Second},
+			{key: "1 quarters", exp: Quarter.Duration()},
+			{key: "1 millennium", err: ErrBadNum},
+			{key: "2 centuries", exp: 2 * Century.Duration()},
+			{key: "5H", err: ErrBadUnit},
+			{key: "3h 5x", err: ErrBadUnit},
+		})
+	})
+	t.Run("case fold", func(t *testing.T) {
+		run(t, NewRelativeParser(SyntaxCaseFold), []stage{
+			{key: "5H", exp: 5 * time.Hour},
+			{key: "5 Minutes", exp: 5 * time.Minute},
+			{key: "1MO", exp: Month.Duration()},
+			{key: "1M", exp: Month.Duration()},
+			{key: "1m", exp: time.Minute},
+		})
+	})
+	t.Run("exact", func(t *testing.T) {
+		run(t, NewRelativeParser(SyntaxDefault|SyntaxExact), []stage{
+			{key: "2d 3h", exp: 51 * time.Hour},
+			{key: "1w", exp: 168 * time.Hour},
+			{key: "1 month", err: ErrCalendarUnit},
+			{key: "2h 1y", err: ErrCalendarUnit},
+		})
+	})
+	t.Run("alias", func(t *testing.T) {
+		p := NewRelativeParser(SyntaxDefault).
+			RegisterAlias("fortnight", Week).
+			RegisterAlias("tick", Millisecond)
+		run(t, p, []stage{
+			{key: "2fortnight", exp: 2 * 168 * time.Hour},
+			{key: "3 Ticks", exp: 3 * time.Millisecond},
+		})
+	})
+	t.Run("locale", func(t *testing.T) {
+		p := NewRelativeParser(SyntaxDefault).RegisterLocale(RelativeLocaleRU)
+		run(t, p, []stage{
+			{key: "1 час 15 минут", exp: time.Hour + 15*time.Minute},
+			{key: "2 ЧАСА", exp: 2 * time.Hour},
+			{key: "3 дня 5 сек", exp: 72*time.Hour + 5*time.Second},
+			{key: "-10мин", exp: -10 * time.Minute},
+			{key: "1 попугай", err: ErrBadUnit},
+		})
+	})
+}
@@ -0,0 +1,582 @@
+package clock
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Freq represents recurrence frequency.
+type Freq uint8
+
+const (
+	FreqUnknown Freq = iota
+	Secondly
+	Minutely
+	Hourly
+	Daily
+	Weekly
+	Monthly
+	Yearly
+)
+
+// rruleMaxEmpty limits number of consecutive periods without occurrences (e.g. BYMONTHDAY=30 with BYMONTH=2).
+// Sub-daily rules skip days filtered out by BYMONTH, BYMONTHDAY and BYDAY at once, so their empty periods are days
+// too. Daily limit covers leap day rules over non-leap centuries.
+var rruleMaxEmpty = [...]int{
+	Secondly: 366 * 9,
+	Minutely: 366 * 9,
+	Hourly:   366 * 9,
+	Daily:    366 * 9,
+	Weekly:   1000,
+	Monthly:  1000,
+	Yearly:   1000,
+}
+
+// WeekdayN represents BYDAY entry, e.g. "-1FR" (last Friday) or "TU" (every Tuesday, N is zero).
+type WeekdayN struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// RRule is a recurrence rule, subset of RFC 5545.
+//
+// Supported parts: FREQ, INTERVAL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS, COUNT, UNTIL and EXDATE. Weeks start on
+// Monday. Time of day of occurrences is taken from Dtstart and kept in Dtstart location, so occurrences follow DST
+// (wall clock fallen into DST gap moves forward by gap size).
+type RRule struct {
+	Freq       Freq
+	Interval   int
+	ByDay      []WeekdayN
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	Count      int
+	Until      time.Time
+	Dtstart    time.Time
+	Exdate     []time.Time
+}
+
+var (
+	rruleFreq = map[string]Freq{
+		"SECONDLY": Secondly, "MINUTELY": Minutely, "HOURLY": Hourly,
+		"DAILY": Daily, "WEEKLY": Weekly, "MONTHLY": Monthly, "YEARLY": Yearly,
+	}
+	rruleDay = map[string]time.Weekday{
+		"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+		"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+	}
+)
+
+// ParseRRule parses recurrence rule.
+//
+// Raw may be a single RRULE value ("FREQ=WEEKLY;BYDAY=TU") or iCalendar-like lines:
+//
+//	DTSTART;TZID=America/New_York:20240102T093000
+//	RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR
+//	EXDATE;TZID=America/New_York:20240704T093000
+//
+// DTSTART line overrides given dtstart.
+func ParseRRule(raw string, dtstart time.Time) (*RRule, error) {
+	r := &RRule{Dtstart: dtstart, Interval: 1}
+	var rule string
+	for _, line := range strings.Split(raw, "\n") {
+		if line = strings.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+		name, params, value := rruleLine(line)
+		switch name {
+		case "DTSTART":
+			t, err := rruleTime(value, params, dtstart.Location())
+			if err != nil {
+				return nil, err
+			}
+			r.Dtstart = t
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				t, err := rruleTime(v, params, r.Dtstart.Location())
+				if err != nil {
+					return nil, err
+				}
+				r.Exdate = append(r.Exdate, t)
+			}
+		case "RRULE", "":
+			if len(rule) > 0 {
+				return nil, ErrBadRRule
+			}
+			rule = value
+		default:
+			return nil, ErrBadRRule
+		}
+	}
+	if len(rule) == 0 {
+		return nil, ErrBadRRule
+	}
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, ErrBadRRule
+		}
+		key, val := strings.ToUpper(strings.TrimSpace(kv[0])), strings.ToUpper(strings.TrimSpace(kv[1]))
+		var err error
+		switch key {
+		case "FREQ":
+			if r.Freq = rruleFreq[val]; r.Freq == FreqUnknown {
+				return nil, ErrBadRRule
+			}
+		case "INTERVAL":
+			if r.Interval, err = strconv.Atoi(val); err != nil || r.Interval < 1 {
+				return nil, ErrBadRRule
+			}
+		case "COUNT":
+			if r.Count, err = strconv.Atoi(val); err != nil || r.Count < 1 {
+				return nil, ErrBadRRule
+			}
+		case "UNTIL":
+			if r.Until, err = rruleTime(val, nil, r.Dtstart.Location()); err != nil {
+				return nil, err
+			}
+		case "BYDAY":
+			for _, s := range strings.Split(val, ",") {
+				if len(s) < 2 {
+					return nil, ErrBadRRule
+				}
+				wd, ok := rruleDay[s[len(s)-2:]]
+				if !ok {
+					return nil, ErrBadRRule
+				}
+				var n int
+				if len(s) > 2 {
+					if n, err = strconv.Atoi(s[:len(s)-2]); err != nil || n == 0 || n > 53 || n < -53 {
+						return nil, ErrBadRRule
+					}
+				}
+				r.ByDay = append(r.ByDay, WeekdayN{N: n, Weekday: wd})
+			}
+		case "BYMONTHDAY":
+			if r.ByMonthDay, err = rruleInts(val, 31); err != nil {
+				return nil, err
+			}
+		case "BYMONTH":
+			var ms []int
+			if ms, err = rruleInts(val, 12); err != nil {
+				return nil, err
+			}
+			for _, m := range ms {
+				if m < 0 {
+					return nil, ErrBadRRule
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			if r.BySetPos, err = rruleInts(val, 366); err != nil {
+				return nil, err
+			}
+		case "WKST":
+			if val != "MO" {
+				return nil, ErrBadRRule
+			}
+		default:
+			return nil, ErrBadRRule
+		}
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func rruleLine(line string) (name string, params map[string]string, value string) {
+	i := strings.IndexByte(line, ':')
+	if i == -1 {
+		return "", nil, line
+	}
+	head := line[:i]
+	value = line[i+1:]
+	parts := strings.Split(head, ";")
+	name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[strings.ToUpper(kv[0])] = kv[1]
+		}
+	}
+	return
+}
+
+func rruleTime(raw string, params map[string]string, loc *time.Location) (time.Time, error) {
+	if tzid, ok := params["TZID"]; ok {
+		var err error
+		if loc, err = LoadLocation(tzid); err != nil {
+			return time.Time{}, err
+		}
+	}
+	raw = strings.TrimSpace(raw)
+	if strings.HasSuffix(raw, "Z") {
+		t, err := time.Parse("20060102T150405Z", raw)
+		if err != nil {
+			return time.Time{}, ErrBadRRule
+		}
+		return t, nil
+	}
+	for _, layout := range [...]string{"20060102T150405", "20060102"} {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return dateIn(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+		}
+	}
+	return time.Time{}, ErrBadRRule
+}
+
+func rruleInts(raw string, limit int) ([]int, error) {
+	var r []int
+	for _, s := range strings.Split(raw, ",") {
+		n, err := strconv.Atoi(s)
+		if err != nil || n == 0 || n > limit || n < -limit {
+			return nil, ErrBadRRule
+		}
+		r = append(r, n)
+	}
+	return r, nil
+}
+
+func (r *RRule) validate() error {
+	if r.Freq == FreqUnknown || r.Dtstart.IsZero() {
+		return ErrBadRRule
+	}
+	if r.Interval < 1 {
+		r.Interval = 1
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return ErrBadRRule
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return ErrBadRRule
+		}
+	}
+	return nil
+}
+
+// Iter returns iterator over occurrences.
+func (r *RRule) Iter() *RRuleIter {
+	return &RRuleIter{r: r}
+}
+
+// Between returns occurrences in range [from, to).
+func (r *RRule) Between(from, to time.Time) []time.Time {
+	var buf []time.Time
+	it := r.Iter()
+	it.seek(from)
+	for {
+		t, ok := it.Next()
+		if !ok || !t.Before(to) {
+			return buf
+		}
+		if !t.Before(from) {
+			buf = append(buf, t)
+		}
+	}
+}
+
+// After returns the first occurrence after t. False means no occurrences left.
+func (r *RRule) After(t time.Time) (time.Time, bool) {
+	return r.Iter().After(t)
+}
+
+// RRuleIter iterates over rule occurrences in chronological order.
+type RRuleIter struct {
+	r      *RRule
+	period int
+	buf    []time.Time
+	pos, n int
+	done   bool
+}
+
+// Next returns next occurrence. False means occurrences are over.
+func (it *RRuleIter) Next() (time.Time, bool) {
+	r := it.r
+	for !it.done {
+		if it.pos < len(it.buf) {
+			t := it.buf[it.pos]
+			it.pos++
+			if r.Count > 0 && it.n >= r.Count || !r.Until.IsZero() && t.After(r.Until) {
+				it.done = true
+				break
+			}
+			it.n++
+			if r.excluded(t) {
+				continue
+			}
+			return t, true
+		}
+		var empty int
+		for it.buf, it.pos = it.expand(it.period), 0; len(it.buf) == 0 && !it.done; it.buf = it.expand(it.period) {
+			if empty++; empty > rruleMaxEmpty[r.Freq] {
+				it.done = true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// After skips occurrences until t and returns the first occurrence after t.
+func (it *RRuleIter) After(t time.Time) (time.Time, bool) {
+	it.seek(t)
+	for {
+		x, ok := it.Next()
+		if !ok || x.After(t) {
+			return x, ok
+		}
+	}
+}
+
+// seek moves iterator forward to a period a bit before t, so occurrences before t aren't generated one by one.
+// Rules with COUNT can't seek, since they count occurrences from dtstart.
+func (it *RRuleIter) seek(t time.Time) {
+	r := it.r
+	start := r.Dtstart
+	if r.Count > 0 || it.done || !t.After(start) {
+		return
+	}
+	t = t.In(start.Location())
+	y, m, d := start.Date()
+	ty, tm, td := t.Date()
+	var n int
+	switch r.Freq {
+	case Secondly, Minutely, Hourly:
+		unit := [...]Unit{Secondly: Second, Minutely: Minute, Hourly: Hour}[r.Freq]
+		n = int(t.Sub(start) / unit.Duration())
+	case Daily:
+		n = civilDays(ty, tm, td) - civilDays(y, m, d)
+	case Weekly:
+		n = (civilDays(ty, tm, td) - civilDays(y, m, d) + int(start.Weekday()+6)%7) / 7
+	case Monthly:
+		n = (ty-y)*12 + int(tm-m)
+	case Yearly:
+		n = ty - y
+	}
+	// Step back by one period to be safe around DST and period edges.
+	if p := n/r.Interval - 1; p > it.period {
+		it.period, it.buf, it.pos = p, nil, 0
+	}
+}
+
+// expand returns sorted occurrences of the period and moves iterator to the next period.
+func (it *RRuleIter) expand(period int) []time.Time {
+	r := it.r
+	it.period++
+	start := r.Dtstart
+	loc := start.Location()
+	y, m, d := start.Date()
+	hh, mm, ss := start.Clock()
+	ns := start.Nanosecond()
+	step := period * r.Interval
+	var buf []time.Time
+	add := func(y int, m time.Month, d int) {
+		buf = append(buf, dateIn(y, m, d, hh, mm, ss, ns, loc))
+	}
+	switch r.Freq {
+	case Secondly, Minutely, Hourly:
+		unit := [...]Unit{Secondly: Second, Minutely: Minute, Hourly: Hour}[r.Freq]
+		t := start.Add(time.Duration(step) * unit.Duration())
+		if !r.Until.IsZero() && t.After(r.Until) {
+			it.done = true
+			return nil
+		}
+		ty, tm, td := t.Date()
+		if r.matchMonth(tm) && r.matchMonthDay(ty, tm, td) && r.matchWeekday(t.Weekday()) {
+			buf = append(buf, t)
+			break
+		}
+		// Skip the rest of filtered out day.
+		per := unit.Duration() * time.Duration(r.Interval)
+		if p := int((dateIn(ty, tm, td+1, 0, 0, 0, 0, loc).Sub(start) + per - 1) / per); p > it.period {
+			it.period = p
+		}
+	case Daily:
+		t := time.Date(y, m, d+step, 0, 0, 0, 0, time.UTC)
+		ty, tm, td := t.Date()
+		if r.matchMonth(tm) && r.matchMonthDay(ty, tm, td) && r.matchWeekday(t.Weekday()) {
+			add(ty, tm, td)
+		}
+	case Weekly:
+		wd := int(start.Weekday()+6) % 7
+		monday := time.Date(y, m, d-wd+step*7, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 7; i++ {
+			t := monday.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && t.Weekday() != start.Weekday() || len(r.ByDay) > 0 && !r.matchWeekday(t.Weekday()) {
+				continue
+			}
+			if ty, tm, td := t.Date(); r.matchMonth(tm) {
+				add(ty, tm, td)
+			}
+		}
+	case Monthly:
+		t := time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		if r.matchMonth(t.Month()) {
+			r.expandMonth(t.Year(), t.Month(), d, add)
+		}
+	case Yearly:
+		ty := y + step
+		switch {
+		case len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0:
+			if d <= daysIn(ty, m) {
+				add(ty, m, d)
+			}
+		case len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0:
+			// BYDAY with offsets relative to the year.
+			ndays := civilDays(ty+1, 1, 1) - civilDays(ty, 1, 1)
+			for _, wdn := range r.ByDay {
+				for _, yd := range nthWeekdays(time.Date(ty, 1, 1, 0, 0, 0, 0, time.UTC).Weekday(), ndays, wdn) {
+					t := time.Date(ty, 1, yd, 0, 0, 0, 0, time.UTC)
+					add(t.Date())
+				}
+			}
+		default:
+			// BYMONTHDAY without BYMONTH expands to every month.
+			if len(r.ByMonth) == 0 {
+				for tm := time.January; tm <= time.December; tm++ {
+					r.expandMonth(ty, tm, d, add)
+				}
+				break
+			}
+			for _, tm := range r.ByMonth {
+				r.expandMonth(ty, tm, d, add)
+			}
+		}
+	}
+	sort.Slice(buf, func(i, j int) bool { return buf[i].Before(buf[j]) })
+	buf = dedupTimes(buf)
+	if len(r.BySetPos) > 0 && len(buf) > 0 {
+		var sel []time.Time
+		for _, pos := range r.BySetPos {
+			i := pos - 1
+			if pos < 0 {
+				i = len(buf) + pos
+			}
+			if i >= 0 && i < len(buf) {
+				sel = append(sel, buf[i])
+			}
+		}
+		sort.Slice(sel, func(i, j int) bool { return sel[i].Before(sel[j]) })
+		buf = dedupTimes(sel)
+	}
+	// Drop occurrences before dtstart (first period).
+	for len(buf) > 0 && buf[0].Before(start) {
+		buf = buf[1:]
+	}
+	return buf
+}
+
+// expandMonth calls add for each matching day of the month. Day d is used if neither BYMONTHDAY nor BYDAY is set.
+func (r *RRule) expandMonth(y int, m time.Month, d int, add func(int, time.Month, int)) {
+	last := daysIn(y, m)
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if d <= last {
+			add(y, m, d)
+		}
+		return
+	}
+	first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	var days []int
+	if len(r.ByDay) > 0 {
+		for _, wdn := range r.ByDay {
+			days = append(days, nthWeekdays(first, last, wdn)...)
+		}
+	} else {
+		for i := 1; i <= last; i++ {
+			days = append(days, i)
+		}
+	}
+	for _, dd := range days {
+		if r.matchMonthDay(y, m, dd) {
+			add(y, m, dd)
+		}
+	}
+}
+
+// nthWeekdays returns days (1-based) matching weekday in range of ndays starting from weekday first.
+func nthWeekdays(first time.Weekday, ndays int, wdn WeekdayN) []int {
+	d1 := 1 + mod(int(wdn.Weekday-first), 7)
+	switch {
+	case wdn.N > 0:
+		if d := d1 + (wdn.N-1)*7; d <= ndays {
+			return []int{d}
+		}
+		return nil
+	case wdn.N < 0:
+		dl := d1 + (ndays-d1)/7*7
+		if d := dl + (wdn.N+1)*7; d >= 1 {
+			return []int{d}
+		}
+		return nil
+	}
+	var r []int
+	for d := d1; d <= ndays; d += 7 {
+		r = append(r, d)
+	}
+	return r
+}
+
+func (r *RRule) matchMonth(m time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, x := range r.ByMonth {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchMonthDay(y int, m time.Month, d int) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(y, m)
+	for _, x := range r.ByMonthDay {
+		if x == d || x < 0 && last+x+1 == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) matchWeekday(wd time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, x := range r.ByDay {
+		if x.Weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) excluded(t time.Time) bool {
+	for _, x := range r.Exdate {
+		if x.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupTimes(buf []time.Time) []time.Time {
+	if len(buf) < 2 {
+		return buf
+	}
+	j := 1
+	for i := 1; i < len(buf); i++ {
+		if !buf[i].Equal(buf[j-1]) {
+			buf[j] = buf[i]
+			j++
+		}
+	}
+	return buf[:j]
+}
@@ -0,0 +1,140 @@
+package clock
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRRule(t *testing.T) {
+	ny := mustLoc(t, "America/New_York")
+	start := time.Date(2024, 1, 1, 18, 0, 0, 0, ny)
+	stages := []struct {
+		key, rule string
+		n         int
+		exp       []string
+	}{
+		{"2nd tuesday", "FREQ=MONTHLY;BYDAY=2TU", 3, []string{
+			"2024-01-09T18:00:00-05:00", "2024-02-13T18:00:00-05:00", "2024-03-12T18:00:00-04:00"}},
+		{"every other tuesday", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", 3, []string{
+			"2024-01-02T18:00:00-05:00", "2024-01-16T18:00:00-05:00", "2024-01-30T18:00:00-05:00"}},
+		{"last business day", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", 6, []string{
+			"2024-01-31T18:00:00-05:00", "2024-02-29T18:00:00-05:00", "2024-03-29T18:00:00-04:00",
+			"2024-04-30T18:00:00-04:00", "2024-05-31T18:00:00-04:00", "2024-06-28T18:00:00-04:00"}},
+		{"weekdays", "DTSTART;TZID=America/New_York:20240307T093000\nRRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=4", 10, []string{
+			"2024-03-07T09:30:00-05:00", "2024-03-08T09:30:00-05:00", "2024-03-11T09:30:00-04:00", "2024-03-12T09:30:00-04:00"}},
+		{"last day of month", "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3", 10, []string{
+			"2024-01-31T18:00:00-05:00", "2024-02-29T18:00:00-05:00", "2024-03-31T18:00:00-04:00"}},
+		{"31st skips short months", "FREQ=MONTHLY;COUNT=3\nDTSTART;TZID=America/New_York:20240131T100000", 10, []string{
+			"2024-01-31T10:00:00-05:00", "2024-03-31T10:00:00-04:00", "2024-05-31T10:00:00-04:00"}},
+		{"thanksgiving", "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", 2, []string{
+			"2024-11-28T18:00:00-05:00", "2025-11-27T18:00:00-05:00"}},
+		{"last monday of year", "FREQ=YEARLY;BYDAY=-1MO", 2, []string{
+			"2024-12-30T18:00:00-05:00", "2025-12-29T18:00:00-05:00"}},
+		{"leap day", "DTSTART;TZID=America/New_York:20240229T120000\nRRULE:FREQ=YEARLY;COUNT=2", 10, []string{
+			"2024-02-29T12:00:00-05:00", "2028-02-29T12:00:00-05:00"}},
+		{"until", "FREQ=DAILY;UNTIL=20240103T230000Z", 10, []string{
+			"2024-01-01T18:00:00-05:00", "2024-01-02T18:00:00-05:00", "2024-01-03T18:00:00-05:00"}},
+		{"exdate", "RRULE:FREQ=DAILY;COUNT=3\nEXDATE;TZID=America/New_York:20240102T180000", 10, []string{
+			"2024-01-01T18:00:00-05:00", "2024-01-03T18:00:00-05:00"}},
+		{"dst gap", "DTSTART;TZID=America/New_York:20240309T023000\nRRULE:FREQ=DAILY;COUNT=3", 10, []string{
+			"2024-03-09T02:30:00-05:00", "2024-03-10T03:30:00-04:00", "2024-03-11T02:30:00-04:00"}},
+		{"dst overlap hourly", "DTSTART:20241103T043000Z\nRRULE:FREQ=HOURLY;COUNT=3", 10, []string{
+			"2024-11-03T04:30:00Z", "2024-11-03T05:30:00Z", "2024-11-03T06:30:00Z"}},
+		{"yearly month days", "FREQ=YEARLY;BYMONTHDAY=1", 3, []string{
+			"2024-01-01T18:00:00-05:00", "2024-02-01T18:00:00-05:00", "2024-03-01T18:00:00-05:00"}},
+		{"sparse secondly", "FREQ=SECONDLY;INTERVAL=30;BYMONTH=3;BYMONTHDAY=10;COUNT=2", 10, []string{
+			"2024-03-10T00:00:00-05:00", "2024-03-10T00:00:30-05:00"}},
+		{"filtered months", "FREQ=DAILY;BYMONTH=3;BYMONTHDAY=1,15", 3, []string{
+			"2024-03-01T18:00:00-05:00", "2024-03-15T18:00:00-04:00", "2025-03-01T18:00:00-05:00"}},
+	}
+	for _, st := range stages {
+		t.Run(st.key, func(t *testing.T) {
+			r, err := ParseRRule(st.rule, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			it := r.Iter()
+			for i := 0; i < st.n; i++ {
+				x, ok := it.Next()
+				if !ok {
+					break
+				}
+				got = append(got, x.Format(time.RFC3339))
+			}
+			if strings.Join(got, " ") != strings.Join(st.exp, " ") {
+				t.Errorf("occurrences mismatch:\nneed %v\ngot  %v", st.exp, got)
+			}
+		})
+	}
+	t.Run("after", func(t *testing.T) {
+		r, _ := ParseRRule("FREQ=MONTHLY;BYDAY=2TU", start)
+		x, ok := r.After(time.Date(2024, 2, 13, 18, 0, 0, 0, ny))
+		if !ok || x.Format(time.RFC3339) != "2024-03-12T18:00:00-04:00" {
+			t.Errorf("after mismatch: %s", x)
+		}
+		if n := len(r.Between(start, time.Date(2025, 1, 1, 0, 0, 0, 0, ny))); n != 12 {
+			t.Errorf("between mismatch: need %d, got %d", 12, n)
+		}
+	})
+	t.Run("seek", func(t *testing.T) {
+		for _, rule := range []string{
+			"FREQ=SECONDLY;BYMONTH=2;BYMONTHDAY=29", "FREQ=MINUTELY;INTERVAL=7", "FREQ=HOURLY;INTERVAL=5;BYDAY=SA",
+			"FREQ=DAILY;INTERVAL=3", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", "FREQ=MONTHLY;BYDAY=-1FR",
+			"FREQ=YEARLY;BYMONTHDAY=15",
+		} {
+			r, _ := ParseRRule(rule, start)
+			from := time.Date(2031, 7, 4, 11, 0, 0, 0, ny)
+			// Seeking iterator must find the same occurrence as iteration from dtstart.
+			it := r.Iter()
+			exp, ok := it.Next()
+			for ok && !exp.After(from) {
+				exp, ok = it.Next()
+			}
+			if x, _ := r.After(from); !ok || !x.Equal(exp) {
+				t.Errorf("rule %q: after mismatch: need %s, got %s", rule, exp, x)
+			}
+			if b := r.Between(from, exp.Add(time.Nanosecond)); len(b) != 1 || !b[0].Equal(exp) {
+				t.Errorf("rule %q: between mismatch: %v", rule, b)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for _, rule := range []string{
+			"", "FREQ=FORTNIGHTLY", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;COUNT=2;UNTIL=20240101",
+			"FREQ=WEEKLY;BYDAY=2TU", "FREQ=MONTHLY;BYDAY=XX", "FREQ=MONTHLY;BYMONTHDAY=32", "FREQ=DAILY;FOO=1",
+			"FREQ=DAILY;WKST=SU", "X-RULE:FREQ=DAILY",
+		} {
+			if _, err := ParseRRule(rule, start); err != ErrBadRRule {
+				t.Errorf("rule %q: need error %v, got %v", rule, ErrBadRRule, err)
+			}
+		}
+	})
+}
+
+func TestScheduleRule(t *testing.T) {
+	var a uint32
+	c := NewClock()
+	c.Start()
+	defer c.Stop()
+	r, err := ParseRRule("FREQ=DAILY;COUNT=2", c.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ScheduleRule(r, func() { atomic.AddUint32(&a, 1) })
+	c.Jump(time.Minute)
+	if v := atomic.LoadUint32(&a); v != 0 {
+		t.Errorf("wrong value: need %d, got %d", 0, v)
+	}
+	c.Jump(time.Hour)
+	if v := atomic.LoadUint32(&a); v != 1 {
+		t.Errorf("wrong value: need %d, got %d", 1, v)
+	}
+	c.Jump(24 * time.Hour)
+	c.Jump(24 * time.Hour)
+	if v := atomic.LoadUint32(&a); v != 2 {
+		t.Errorf("wrong value: need %d, got %d", 2, v)
+	}
+}
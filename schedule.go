@@ -1,60 +1,546 @@
 package clock
 
 import (
+	"context"
+	"math"
+	"math/rand"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// Job is a scheduled job. Context is cancelled when clock stops.
+type Job func(ctx context.Context) error
+
+// Misfire is a policy of overdue runs of a job, e.g. after process suspend or forward Jump.
+type Misfire uint8
+
+const (
+	// MisfireFireOnce runs overdue job once and schedules the next run an interval after now.
+	MisfireFireOnce Misfire = iota
+	// MisfireFireAll runs every missed occurrence (up to JobOptions.MisfireCap) and keeps the original grid of runs.
+	MisfireFireAll
+	// MisfireSkip skips missed occurrences and keeps the original grid of runs. Run late by less than an interval
+	// isn't missed and runs.
+	MisfireSkip
+)
+
+// defaultMisfireCap limits catch-up runs of MisfireFireAll.
+const defaultMisfireCap = 100
+
+// JobOptions describes scheduling policy of a job.
+type JobOptions struct {
+	// Delay before the first run, default is job interval.
+	InitialDelay time.Duration
+	// Random delay up to Jitter added to each run, spreads jobs of many processes scheduled at the same time.
+	Jitter time.Duration
+	// Random delay up to JitterPercent (0..100) of job interval, added to Jitter.
+	JitterPercent float64
+	// Failed job is retried after exponential backoff: BackoffMin * BackoffFactor^(attempt-1) up to BackoffMax.
+	// Zero BackoffMin disables retries: failed job waits for its regular run.
+	BackoffMin, BackoffMax time.Duration
+	// Backoff growth factor, default is 2.
+	BackoffFactor float64
+	// Max attempts of a run (first run and retries). Exhausted run is given up: job waits for its regular run.
+	// Zero means unlimited retries.
+	MaxAttempts int
+	// OnError is called on each failed attempt.
+	OnError func(err error, attempt int)
+	// OnGiveUp is called when attempts of a run are exhausted.
+	OnGiveUp func(err error)
+	// Policy of overdue runs, default is MisfireFireOnce.
+	Misfire Misfire
+	// Max catch-up runs of MisfireFireAll, default is 100.
+	MisfireCap int
+	// Keep next run on backward step of clock: job is postponed by step size. By default, next run is shifted by
+	// the step, so the interval is kept.
+	KeepOnBackward bool
+	// Name of the job in Clock.Store. Named job restores its next run on restart, overdue run is handled by
+	// Misfire policy.
+	Name string
+}
+
 type sched struct {
+	// Counters of runs, panics and skipped ticks, see Clock.Stats().
+	runs, panics, skipped uint64
+	// Last job ID.
+	id uint64
+
 	spinlock uint32
 	mux      sync.RWMutex
-	buf      []schedRule
+	buf      []*schedRule
+	// Buffer of due runs, protected by spinlock.
+	due []schedRun
+	// Held (read) while jobs run, see wait().
+	run sync.RWMutex
+	// Context of jobs, see setContext().
+	ctx context.Context
+	// Owner clock providing metrics and hooks.
+	clock *Clock
+
+	// Pending states of named jobs and done channel of running saver, see save().
+	smux   sync.Mutex
+	saves  map[string]JobState
+	saving chan struct{}
+}
+
+// schedRun is a due run of a job.
+type schedRun struct {
+	r  *schedRule
+	at time.Time
 }
 
 type schedRule struct {
+	id   uint64
 	fn   func()
 	dur  time.Duration
+	iter *RRuleIter
 	next time.Time
+	// Scheduled time of the next run without jitter, grid of MisfireFireAll and MisfireSkip jobs.
+	grid time.Time
+	done bool
+	// One-shot timer state, see clockTimer.
+	timer *clockTimer
+	// Cancel handle of periodic job, see Clock.Every.
+	cancel *schedHandle
+	// Job with error and its policy.
+	job  Job
+	opts *JobOptions
+	// Failed attempts of the current run.
+	fails int
 }
 
-func (s *sched) slock() {
-	atomic.StoreUint32(&s.spinlock, 1)
+// slock tries to acquire spinlock, false means it's held by another apply.
+func (s *sched) slock() bool {
+	return atomic.CompareAndSwapUint32(&s.spinlock, 0, 1)
 }
 
 func (s *sched) sunlock() {
 	atomic.StoreUint32(&s.spinlock, 0)
 }
 
-func (s *sched) slocked() bool {
-	return atomic.LoadUint32(&s.spinlock) == 1
-}
-
 func (s *sched) register(dur time.Duration, fn func(), now time.Time) {
 	s.mux.Lock()
 	defer s.mux.Unlock()
-	s.buf = append(s.buf, schedRule{
+	s.add(&schedRule{
 		fn:   fn,
 		dur:  dur,
 		next: now.Add(dur),
 	})
 }
 
+func (s *sched) registerEvery(dur time.Duration, fn func(), now time.Time) *schedHandle {
+	h := &schedHandle{s: s}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.add(&schedRule{
+		fn:     fn,
+		dur:    dur,
+		next:   now.Add(dur),
+		cancel: h,
+	})
+	return h
+}
+
+func (s *sched) registerJob(dur time.Duration, job Job, opts JobOptions, now time.Time) {
+	r := &schedRule{
+		job:  job,
+		dur:  dur,
+		opts: &opts,
+	}
+	delay := opts.InitialDelay
+	if delay <= 0 {
+		delay = dur
+	}
+	r.grid = now.Add(delay)
+	if st := s.store(); st != nil && opts.Name != "" {
+		state, ok, err := st.Load(opts.Name)
+		switch {
+		case err != nil:
+			s.storeError(opts.Name, err)
+		case ok && !state.Next.IsZero():
+			r.grid = state.Next
+		default:
+			s.storeError(opts.Name, st.Save(JobState{Name: opts.Name, Next: r.grid}))
+		}
+	}
+	r.next = r.grid.Add(r.jitter())
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.add(r)
+}
+
+func (s *sched) registerTimer(t *clockTimer, fn func(), at time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	// Drop finished jobs to prevent growth of buffer by one-shot timers.
+	s.compact()
+	s.add(&schedRule{
+		fn:    fn,
+		next:  at,
+		timer: t,
+	})
+}
+
+func (s *sched) registerRule(iter *RRuleIter, fn func(), now time.Time) {
+	next, ok := iter.After(now)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.add(&schedRule{
+		fn:   fn,
+		iter: iter,
+		next: next,
+		done: !ok,
+	})
+}
+
+// compact drops finished and cancelled jobs. Must be called under lock.
+func (s *sched) compact() {
+	buf := s.buf[:0]
+	for i := 0; i < len(s.buf); i++ {
+		if r := s.buf[i]; r.alive() {
+			buf = append(buf, r)
+		}
+	}
+	for i := len(buf); i < len(s.buf); i++ {
+		s.buf[i] = nil
+	}
+	s.buf = buf
+}
+
+// add adds rule. Must be called under lock.
+func (s *sched) add(r *schedRule) {
+	s.id++
+	r.id = s.id
+	s.buf = append(s.buf, r)
+}
+
+// shift moves next runs of interval jobs by delta. Rule jobs keep their calendar occurrences.
+func (s *sched) shift(delta time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for i := 0; i < len(s.buf); i++ {
+		if r := s.buf[i]; r.iter == nil && r.timer == nil && (r.opts == nil || !r.opts.KeepOnBackward) {
+			r.next, r.grid = r.next.Add(delta), r.grid.Add(delta)
+		}
+	}
+}
+
+// setContext sets context of jobs. Nil means background.
+func (s *sched) setContext(ctx context.Context) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.ctx = ctx
+}
+
 func (s *sched) apply(now time.Time) {
-	if len(s.buf) == 0 || s.slocked() {
+	if !s.slock() {
+		atomic.AddUint64(&s.skipped, 1)
+		if m := s.metrics(); m != nil {
+			m.TickSkipped()
+		}
 		return
 	}
-	s.slock()
-	s.mux.RLock()
+	defer s.sunlock()
+	s.mux.Lock()
+	s.due = s.due[:0]
+	for i := 0; i < len(s.buf); i++ {
+		r := s.buf[i]
+		if r.done || r.cancel != nil && !r.cancel.active() {
+			continue
+		}
+		if r.timer != nil {
+			// One-shot timer fires at its time or later.
+			if !now.Before(r.next) {
+				r.done = true
+				if r.timer.fire() {
+					s.due = append(s.due, schedRun{r: r, at: r.next})
+				}
+			}
+			continue
+		}
+		if r.next.Before(now) {
+			at, runs := r.next, 1
+			switch {
+			case r.iter != nil:
+				var ok bool
+				r.next, ok = r.iter.After(now)
+				r.done = !ok
+			case r.opts != nil && r.opts.Misfire != MisfireFireOnce && r.dur > 0:
+				// Count occurrences of grid in (grid, now) and advance it. Grid ahead of now means retry of failed
+				// run, the regular run stays on grid.
+				if r.grid.Before(now) {
+					missed := (int64(now.Sub(r.grid)) - 1) / int64(r.dur)
+					r.grid = r.grid.Add(time.Duration(missed+1) * r.dur)
+					if r.opts.Misfire == MisfireSkip {
+						if missed > 0 {
+							runs = 0
+						}
+					} else {
+						limit := r.opts.MisfireCap
+						if limit <= 0 {
+							limit = defaultMisfireCap
+						}
+						if runs += int(missed); missed >= int64(limit) {
+							runs = limit
+						}
+					}
+				}
+				r.next = r.grid.Add(r.jitter())
+			default:
+				r.grid = now.Add(r.dur)
+				r.next = r.grid.Add(r.jitter())
+			}
+			for i := 0; i < runs; i++ {
+				s.due = append(s.due, schedRun{r: r, at: at})
+				if r.dur > 0 {
+					at = at.Add(r.dur)
+				}
+			}
+		}
+	}
+	ctx := s.ctx
+	s.run.RLock()
+	defer s.run.RUnlock()
+	s.mux.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Call jobs outside of lock, so they may schedule new jobs.
+	mono := monoNow()
+	for i := 0; i < len(s.due); i++ {
+		s.exec(s.due[i], ctx, now, mono)
+		s.due[i] = schedRun{}
+	}
+}
+
+// exec runs due job with hooks and metrics. Mono is monotonic reading taken at tick time now, it accounts time spent
+// by previous jobs of the tick in lag.
+func (s *sched) exec(run schedRun, ctx context.Context, now time.Time, mono int64) {
+	r := run.r
+	if r.cancel != nil && !r.cancel.active() {
+		// Cancelled after the tick found it due.
+		return
+	}
+	lag := now.Sub(run.at) + time.Duration(monoNow()-mono)
+	info := JobInfo{ID: r.id, Scheduled: run.at, Lag: lag}
+	c := s.clock
+	if c != nil && c.BeforeRun != nil {
+		c.BeforeRun(info)
+	}
+	start := monoNow()
+	err := s.call(r, ctx)
+	dur := time.Duration(monoNow() - start)
+	atomic.AddUint64(&s.runs, 1)
+	if m := s.metrics(); m != nil {
+		m.JobRun(info.Lag, dur)
+	}
+	if c != nil && c.AfterRun != nil {
+		c.AfterRun(info, dur, err)
+	}
+	if r.job != nil {
+		s.complete(r, err, now)
+		s.save(r, now)
+	}
+}
+
+// call calls job and handles its panic.
+func (s *sched) call(r *schedRule, ctx context.Context) (err error) {
 	defer func() {
-		s.mux.RUnlock()
-		s.sunlock()
+		if p := recover(); p != nil {
+			atomic.AddUint64(&s.panics, 1)
+			if m := s.metrics(); m != nil {
+				m.JobPanic(p)
+			}
+			if s.clock == nil || s.clock.OnPanic == nil {
+				panic(p)
+			}
+			s.clock.OnPanic(p)
+			err = ErrJobPanic
+		}
 	}()
+	if r.job != nil {
+		return r.job(ctx)
+	}
+	r.fn()
+	return nil
+}
+
+// save queues state of named job for saver goroutine, so slow store doesn't stall ticks.
+func (s *sched) save(r *schedRule, last time.Time) {
+	st := s.store()
+	if st == nil || r.opts.Name == "" {
+		return
+	}
+	s.mux.RLock()
+	next := r.grid
+	s.mux.RUnlock()
+	s.smux.Lock()
+	defer s.smux.Unlock()
+	if s.saves == nil {
+		s.saves = make(map[string]JobState)
+	}
+	s.saves[r.opts.Name] = JobState{Name: r.opts.Name, Last: last, Next: next}
+	if s.saving == nil {
+		s.saving = make(chan struct{})
+		go s.saver(st, s.saving)
+	}
+}
+
+// saver saves queued states in batches until queue is empty.
+func (s *sched) saver(st JobStore, done chan struct{}) {
+	var batch []JobState
+	for {
+		s.smux.Lock()
+		if len(s.saves) == 0 {
+			s.saving = nil
+			close(done)
+			s.smux.Unlock()
+			return
+		}
+		batch = batch[:0]
+		for _, state := range s.saves {
+			batch = append(batch, state)
+		}
+		s.saves = nil
+		s.smux.Unlock()
+		if err := st.Save(batch...); err != nil {
+			for i := 0; i < len(batch); i++ {
+				s.storeError(batch[i].Name, err)
+			}
+		}
+	}
+}
+
+func (s *sched) store() JobStore {
+	if s.clock == nil {
+		return nil
+	}
+	return s.clock.Store
+}
+
+func (s *sched) storeError(name string, err error) {
+	if err != nil && s.clock != nil && s.clock.OnStoreError != nil {
+		s.clock.OnStoreError(name, err)
+	}
+}
+
+func (s *sched) metrics() SchedMetrics {
+	if s.clock == nil {
+		return nil
+	}
+	return s.clock.Metrics
+}
+
+// complete applies retry policy to the result of job run.
+func (s *sched) complete(r *schedRule, err error, now time.Time) {
+	o := r.opts
+	s.mux.Lock()
+	if err == nil {
+		r.fails = 0
+		s.mux.Unlock()
+		return
+	}
+	r.fails++
+	attempt, giveUp := r.fails, o.MaxAttempts > 0 && r.fails >= o.MaxAttempts
+	if giveUp {
+		r.fails = 0
+	} else if o.BackoffMin > 0 {
+		if retry := now.Add(r.backoff()); retry.Before(r.next) {
+			r.next = retry
+		}
+	}
+	s.mux.Unlock()
+	if o.OnError != nil {
+		o.OnError(err, attempt)
+	}
+	if giveUp && o.OnGiveUp != nil {
+		o.OnGiveUp(err)
+	}
+}
+
+// pending checks if there are jobs to run.
+func (s *sched) pending() bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
 	for i := 0; i < len(s.buf); i++ {
-		r := &s.buf[i]
-		if r.next.Before(now) {
-			r.next = now.Add(r.dur)
-			r.fn()
+		if s.buf[i].alive() {
+			return true
 		}
 	}
+	return false
+}
+
+// count returns count of active jobs.
+func (s *sched) count() (n int) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	for i := 0; i < len(s.buf); i++ {
+		if s.buf[i].alive() {
+			n++
+		}
+	}
+	return
+}
+
+// wait blocks until in-flight jobs and pending saves finish.
+func (s *sched) wait() {
+	s.run.Lock()
+	s.run.Unlock()
+	s.smux.Lock()
+	saving := s.saving
+	s.smux.Unlock()
+	if saving != nil {
+		<-saving
+	}
+}
+
+func (r *schedRule) alive() bool {
+	return !r.done && (r.timer == nil || r.timer.active()) && (r.cancel == nil || r.cancel.active())
+}
+
+// schedHandle is a cancel handle of periodic job.
+type schedHandle struct {
+	clockTimer
+	s *sched
+}
+
+// Stop unregisters the job. Returns false if it's already stopped.
+func (h *schedHandle) Stop() bool {
+	if !h.clockTimer.Stop() {
+		return false
+	}
+	h.s.mux.Lock()
+	h.s.compact()
+	h.s.mux.Unlock()
+	return true
+}
+
+// jitter returns random delay of the next run according to job options.
+func (r *schedRule) jitter() time.Duration {
+	if r.opts == nil {
+		return 0
+	}
+	max := r.opts.Jitter + time.Duration(float64(r.dur)*r.opts.JitterPercent/100)
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// backoff returns delay before retry of failed job.
+func (r *schedRule) backoff() time.Duration {
+	o := r.opts
+	factor := o.BackoffFactor
+	if factor <= 1 {
+		factor = 2
+	}
+	d := float64(o.BackoffMin) * math.Pow(factor, float64(r.fails-1))
+	if o.BackoffMax > 0 && d > float64(o.BackoffMax) {
+		return o.BackoffMax
+	}
+	if d > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
 }
@@ -1,6 +1,7 @@
 package clock
 
 import (
+	"context"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -45,3 +46,225 @@ func TestSchedule(t *testing.T) {
 		c.Stop()
 	})
 }
+
+func TestEvery(t *testing.T) {
+	var a uint32
+	c := NewClock()
+	c.SetRate(0)
+	h := c.Every(time.Minute, func() { atomic.AddUint32(&a, 1) })
+	c.Jump(time.Minute + time.Second)
+	if !h.Stop() || h.Stop() {
+		t.Error("the first stop must succeed only")
+	}
+	c.Jump(time.Hour)
+	if v := atomic.LoadUint32(&a); v != 1 {
+		t.Errorf("stopped job must not run: %d runs", v)
+	}
+	if n := c.Stats().Jobs; n != 0 || len(c.sched.buf) != 0 {
+		t.Errorf("stopped job must be unregistered: %d jobs", n)
+	}
+}
+
+func TestScheduleSet(t *testing.T) {
+	var a uint32
+	c := NewClock()
+	c.SetRate(0)
+	c.Schedule(time.Minute, func() { atomic.AddUint32(&a, 1) })
+	c.Set(c.Now().Add(-time.Hour))
+	c.Jump(time.Minute + time.Second)
+	if v := atomic.LoadUint32(&a); v != 1 {
+		t.Errorf("backward set must not postpone job: need %d, got %d", 1, v)
+	}
+	c.Set(c.Now().Add(time.Hour))
+	if v := atomic.LoadUint32(&a); v != 2 {
+		t.Errorf("forward set must run overdue job once: need %d, got %d", 2, v)
+	}
+}
+
+func TestAfterFunc(t *testing.T) {
+	var a, b uint32
+	c := NewClock()
+	c.Start()
+	defer c.Stop()
+	c.AfterFunc(time.Hour, func() { atomic.AddUint32(&a, 1) })
+	tb := c.AfterFunc(time.Hour, func() { atomic.AddUint32(&b, 1) })
+	if !tb.Stop() || tb.Stop() {
+		t.Error("stop mismatch")
+	}
+	c.Jump(time.Hour)
+	c.Jump(time.Hour)
+	if v := atomic.LoadUint32(&a); v != 1 {
+		t.Errorf("timer must fire once: need %d, got %d", 1, v)
+	}
+	if v := atomic.LoadUint32(&b); v != 0 {
+		t.Errorf("stopped timer must not fire: got %d", v)
+	}
+}
+
+func TestScheduleJob(t *testing.T) {
+	t.Run("jitter", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		var a uint32
+		c.ScheduleJob(time.Minute, func(context.Context) error {
+			atomic.AddUint32(&a, 1)
+			return nil
+		}, JobOptions{InitialDelay: time.Second, Jitter: 10 * time.Second})
+		c.Jump(time.Second)
+		if c.sched.buf[0].next.Sub(c.Now()) >= 10*time.Second {
+			t.Fatal("jitter exceeds limit")
+		}
+		c.Jump(10 * time.Second)
+		if v := atomic.LoadUint32(&a); v != 1 {
+			t.Fatalf("job must run after initial delay and jitter, %d runs", v)
+		}
+		next := c.sched.buf[0].next.Sub(c.Now())
+		if next < time.Minute || next >= time.Minute+10*time.Second {
+			t.Errorf("next run mismatch: %s", next)
+		}
+	})
+	t.Run("backoff", func(t *testing.T) {
+		c := NewClock()
+		c.SetRate(0)
+		var (
+			attempts []int
+			gaveUp   int
+			fail     = true
+			last     time.Time
+			gaps     []time.Duration
+		)
+		c.ScheduleJob(time.Hour, func(context.Context) error {
+			if !last.IsZero() {
+				gaps = append(gaps, c.Now().Sub(last))
+			}
+			last = c.Now()
+			if fail {
+				return ErrNoDur
+			}
+			return nil
+		}, JobOptions{
+			BackoffMin:  time.Second,
+			BackoffMax:  5 * time.Second,
+			MaxAttempts: 5,
+			OnError:     func(_ error, attempt int) { attempts = append(attempts, attempt) },
+			OnGiveUp:    func(error) { gaveUp++ },
+		})
+		c.Jump(time.Hour + time.Millisecond)
+		for i := 0; i < 40; i++ {
+			c.Jump(500 * time.Millisecond)
+		}
+		if len(attempts) != 5 || attempts[4] != 5 || gaveUp != 1 {
+			t.Fatalf("attempts mismatch: %v, gave up %d", attempts, gaveUp)
+		}
+		want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+		for i := range want {
+			if gaps[i] < want[i] || gaps[i] > want[i]+500*time.Millisecond {
+				t.Errorf("#%d backoff mismatch: %s", i, gaps[i])
+			}
+		}
+		fail = false
+		c.Jump(time.Hour)
+		if len(attempts) != 5 || len(gaps) != 5 {
+			t.Error("given up job must wait for regular run")
+		}
+	})
+	t.Run("context", func(t *testing.T) {
+		c := NewClock()
+		c.Start()
+		done := make(chan error, 1)
+		c.ScheduleJob(time.Millisecond, func(ctx context.Context) error {
+			select {
+			case done <- nil:
+			default:
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		}, JobOptions{})
+		<-done
+		c.Stop()
+	})
+}
+
+func TestScheduleMisfire(t *testing.T) {
+	run := func(opts JobOptions, fn func(c *Clock)) (runs int) {
+		c := NewClock()
+		c.SetRate(0)
+		c.ScheduleJob(time.Minute, func(context.Context) error {
+			runs++
+			return nil
+		}, opts)
+		fn(c)
+		return
+	}
+	forward := func(c *Clock) {
+		c.Jump(10*time.Minute + time.Second)
+	}
+	if n := run(JobOptions{}, forward); n != 1 {
+		t.Errorf("fire once mismatch: %d runs", n)
+	}
+	if n := run(JobOptions{Misfire: MisfireFireAll}, forward); n != 10 {
+		t.Errorf("fire all mismatch: %d runs", n)
+	}
+	if n := run(JobOptions{Misfire: MisfireFireAll, MisfireCap: 3}, forward); n != 3 {
+		t.Errorf("fire all with cap mismatch: %d runs", n)
+	}
+	if n := run(JobOptions{Misfire: MisfireSkip}, forward); n != 0 {
+		t.Errorf("skip mismatch: %d runs", n)
+	}
+	grid := func(c *Clock) {
+		forward(c)
+		// Grid is kept: the next run is at 11m from start.
+		c.Jump(59 * time.Second)
+		c.Jump(time.Second)
+	}
+	if n := run(JobOptions{Misfire: MisfireSkip}, grid); n != 1 {
+		t.Errorf("skip must keep grid: %d runs", n)
+	}
+	late := func(c *Clock) {
+		c.Jump(time.Minute + time.Second)
+	}
+	if n := run(JobOptions{Misfire: MisfireSkip}, late); n != 1 {
+		t.Errorf("late run isn't missed: %d runs", n)
+	}
+	backward := func(c *Clock) {
+		c.Jump(30 * time.Second)
+		c.Jump(-time.Hour)
+		c.Jump(31 * time.Second)
+	}
+	if n := run(JobOptions{}, backward); n != 1 {
+		t.Errorf("backward step must keep interval: %d runs", n)
+	}
+	if n := run(JobOptions{KeepOnBackward: true}, backward); n != 0 {
+		t.Errorf("backward step must postpone job: %d runs", n)
+	}
+	frozen := func(c *Clock) {
+		c.Backward = BackwardFreeze
+		c.Jump(30 * time.Second)
+		c.Jump(-time.Hour)
+		c.Jump(time.Hour + 31*time.Second)
+	}
+	if n := run(JobOptions{}, frozen); n != 1 {
+		t.Errorf("frozen clock mismatch: %d runs", n)
+	}
+	t.Run("jitter", func(t *testing.T) {
+		var runs int
+		c := NewClock()
+		c.SetRate(0)
+		start := c.Now()
+		c.BeforeRun = func(info JobInfo) {
+			runs++
+			// Jitter must not accumulate: each run is within Jitter after its grid time.
+			if off := info.Scheduled.Sub(start) % time.Minute; off >= 20*time.Second {
+				t.Errorf("run drifted from grid by %s", off)
+			}
+		}
+		c.ScheduleJob(time.Minute, func(context.Context) error { return nil },
+			JobOptions{Jitter: 20 * time.Second, Misfire: MisfireSkip})
+		for i := 0; i < 1200; i++ {
+			c.Jump(5 * time.Second)
+		}
+		if runs < 95 {
+			t.Errorf("runs mismatch: %d", runs)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package clock
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JobState is a persisted state of named job.
+type JobState struct {
+	Name string
+	// Time of the last run, zero if job never ran.
+	Last time.Time
+	// Scheduled time of the next run without jitter.
+	Next time.Time
+}
+
+// JobStore is a storage of jobs state, see Clock.Store. Methods are called by scheduler and must be thread-safe.
+type JobStore interface {
+	// Load returns state of named job. False means no state.
+	Load(name string) (JobState, bool, error)
+	// Save stores states of jobs. Scheduler saves states asynchronously and batches states of jobs run meanwhile.
+	Save(states ...JobState) error
+}
+
+// File store format: magic, uvarint count of jobs, jobs (uvarint name length, name, varint last and next in unix
+// nanoseconds, zero time is 0) and CRC32 (IEEE, big endian) of all preceding bytes.
+const fileStoreMagic = "CLKJ\x01"
+
+// FileStore is a JobStore keeping states of all jobs in a single file. The file is rewritten atomically on each
+// save (temp file and rename), so crash leaves either old or new version of it.
+type FileStore struct {
+	mux    sync.Mutex
+	path   string
+	states map[string]JobState
+	buf    []byte
+}
+
+// OpenFileStore makes store and loads its states from file at path. Missing file means empty store.
+// Corrupted file causes ErrBadJobStore; remove the file to start from scratch.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:   path,
+		states: make(map[string]JobState),
+	}
+	p, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = s.decode(p); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) Load(name string) (JobState, bool, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	st, ok := s.states[name]
+	return st, ok, nil
+}
+
+func (s *FileStore) Save(states ...JobState) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	prev := make([]JobState, len(states))
+	for i, state := range states {
+		prev[i] = s.states[state.Name]
+		s.states[state.Name] = state
+	}
+	if err := s.write(); err != nil {
+		// Keep memory in sync with file.
+		for i := len(states) - 1; i >= 0; i-- {
+			if prev[i].Name == "" {
+				delete(s.states, states[i].Name)
+			} else {
+				s.states[states[i].Name] = prev[i]
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes state of named job.
+func (s *FileStore) Delete(name string) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	prev, ok := s.states[name]
+	if !ok {
+		return nil
+	}
+	delete(s.states, name)
+	if err := s.write(); err != nil {
+		s.states[name] = prev
+		return err
+	}
+	return nil
+}
+
+// write writes states to temp file and renames it to the path. Must be called under lock.
+func (s *FileStore) write() (err error) {
+	s.buf = s.encode(s.buf[:0])
+	dir, base := filepath.Split(s.path)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := os.CreateTemp(dir, base+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if _, err = f.Write(s.buf); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(f.Name(), s.path); err != nil {
+		return err
+	}
+	// Make rename durable.
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *FileStore) encode(dst []byte) []byte {
+	dst = append(dst, fileStoreMagic...)
+	dst = appendUvarint(dst, uint64(len(s.states)))
+	for _, st := range s.states {
+		dst = appendUvarint(dst, uint64(len(st.Name)))
+		dst = append(dst, st.Name...)
+		dst = appendVarint(dst, storeTime(st.Last))
+		dst = appendVarint(dst, storeTime(st.Next))
+	}
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(dst))
+	return append(dst, sum[:]...)
+}
+
+func (s *FileStore) decode(p []byte) error {
+	if len(p) < len(fileStoreMagic)+4 || string(p[:len(fileStoreMagic)]) != fileStoreMagic {
+		return ErrBadJobStore
+	}
+	body, sum := p[:len(p)-4], p[len(p)-4:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return ErrBadJobStore
+	}
+	p = body[len(fileStoreMagic):]
+	cnt, i := binary.Uvarint(p)
+	if i <= 0 || cnt > uint64(len(p)) {
+		return ErrBadJobStore
+	}
+	p = p[i:]
+	for ; cnt > 0; cnt-- {
+		l, i := binary.Uvarint(p)
+		if i <= 0 || l > uint64(len(p)-i) {
+			return ErrBadJobStore
+		}
+		st := JobState{Name: string(p[i : i+int(l)])}
+		p = p[i+int(l):]
+		last, i := binary.Varint(p)
+		if i <= 0 {
+			return ErrBadJobStore
+		}
+		p = p[i:]
+		next, i := binary.Varint(p)
+		if i <= 0 {
+			return ErrBadJobStore
+		}
+		p = p[i:]
+		st.Last, st.Next = loadTime(last), loadTime(next)
+		s.states[st.Name] = st
+	}
+	if len(p) > 0 {
+		return ErrBadJobStore
+	}
+	return nil
+}
+
+func appendVarint(dst []byte, x int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], x)
+	return append(dst, buf[:n]...)
+}
+
+func storeTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func loadTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+var _ JobStore = (*FileStore)(nil)
@@ -0,0 +1,96 @@
+package clock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Run("reopen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs")
+		s, err := OpenFileStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next := time.Unix(1700000000, 123)
+		if err = s.Save(JobState{Name: "daily", Last: next.Add(-24 * time.Hour), Next: next}); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Save(JobState{Name: "weekly", Next: next}); err != nil {
+			t.Fatal(err)
+		}
+		if s, err = OpenFileStore(path); err != nil {
+			t.Fatal(err)
+		}
+		st, ok, _ := s.Load("daily")
+		if !ok || !st.Next.Equal(next) || !st.Last.Equal(next.Add(-24*time.Hour)) {
+			t.Errorf("state mismatch: %+v", st)
+		}
+		if st, _, _ = s.Load("weekly"); !st.Last.IsZero() {
+			t.Errorf("zero time mismatch: %s", st.Last)
+		}
+		if _, ok, _ = s.Load("hourly"); ok {
+			t.Error("unknown job must have no state")
+		}
+		if files, _ := os.ReadDir(filepath.Dir(path)); len(files) != 1 {
+			t.Errorf("temp files must not remain: %d files", len(files))
+		}
+	})
+	t.Run("corrupt", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "jobs")
+		s, _ := OpenFileStore(path)
+		_ = s.Save(JobState{Name: "daily", Next: time.Unix(1700000000, 0)})
+		p, _ := os.ReadFile(path)
+		flip := append([]byte{}, p...)
+		flip[8] ^= 1
+		for _, bad := range [][]byte{p[:len(p)-1], flip, nil} {
+			_ = os.WriteFile(path, bad, 0644)
+			if _, err := OpenFileStore(path); err != ErrBadJobStore {
+				t.Errorf("corruption must be detected: %v", err)
+			}
+		}
+	})
+}
+
+func TestScheduleStore(t *testing.T) {
+	var runs int
+	path := filepath.Join(t.TempDir(), "jobs")
+	job := func(context.Context) error {
+		runs++
+		return nil
+	}
+	opts := JobOptions{Name: "daily", Misfire: MisfireFireAll}
+
+	c := NewClock()
+	c.SetRate(0)
+	c.Store, _ = OpenFileStore(path)
+	start := c.Now()
+	c.ScheduleJob(24*time.Hour, job, opts)
+	c.Jump(25 * time.Hour)
+	if runs != 1 {
+		t.Fatalf("wrong runs: need %d, got %d", 1, runs)
+	}
+
+	// Wait for saver.
+	c.Stop()
+
+	// Restart: the next run is restored from store and missed runs are fired by policy.
+	runs = 0
+	c = NewClock()
+	c.SetRate(0)
+	c.Store, _ = OpenFileStore(path)
+	c.Set(start)
+	c.ScheduleJob(24*time.Hour, job, opts)
+	c.Jump(73 * time.Hour)
+	if runs != 2 {
+		t.Errorf("wrong runs after restart: need %d, got %d", 2, runs)
+	}
+	c.Stop()
+	st, _, _ := c.Store.Load("daily")
+	if !st.Next.Equal(start.Add(96*time.Hour)) || !st.Last.Equal(start.Add(73*time.Hour)) {
+		t.Errorf("state mismatch: %+v", st)
+	}
+}
@@ -0,0 +1,84 @@
+package clock
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timer represents one-shot timer.
+type Timer interface {
+	// Stop prevents the timer from firing. Returns false if timer already fired or stopped.
+	Stop() bool
+}
+
+// TimerInterface is an extension of Interface providing one-shot timers in clock time.
+type TimerInterface interface {
+	Interface
+	// AfterFunc calls fn in its own context after duration d elapsed in clock time.
+	AfterFunc(d time.Duration, fn func()) Timer
+}
+
+const (
+	timerActive uint32 = iota
+	timerFired
+	timerStopped
+)
+
+// clockTimer is a one-shot timer of Clock scheduler.
+type clockTimer struct {
+	state uint32
+}
+
+func (t *clockTimer) Stop() bool {
+	return atomic.CompareAndSwapUint32(&t.state, timerActive, timerStopped)
+}
+
+func (t *clockTimer) fire() bool {
+	return atomic.CompareAndSwapUint32(&t.state, timerActive, timerFired)
+}
+
+func (t *clockTimer) active() bool {
+	return atomic.LoadUint32(&t.state) == timerActive
+}
+
+// AfterFunc calls fn after duration d elapsed in clock time. Fn is called by clock ticker (or by Jump/Set caller)
+// and should not block.
+//
+// Timer respects Jump, Set, Slew and SetRate, so clock-driven code may be tested deterministically.
+func (c *Clock) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &clockTimer{}
+	c.getSched().registerTimer(t, fn, c.clockNow().Add(d))
+	if d <= 0 {
+		c.tick()
+	}
+	c.wakeup()
+	return t
+}
+
+// AfterFunc calls fn after duration d using time.AfterFunc.
+func (c Native) AfterFunc(d time.Duration, fn func()) Timer {
+	return time.AfterFunc(d, fn)
+}
+
+// AfterFunc calls fn immediately if d isn't positive. Stuck clock never reaches future, so other timers never fire.
+func (c Stuck) AfterFunc(d time.Duration, fn func()) Timer {
+	t := &clockTimer{}
+	if d <= 0 && t.fire() {
+		go fn()
+	}
+	return t
+}
+
+// afterFunc calls fn after duration d using timers of c if it implements TimerInterface, otherwise real timer.
+func afterFunc(c Interface, d time.Duration, fn func()) Timer {
+	if tc, ok := c.(TimerInterface); ok {
+		return tc.AfterFunc(d, fn)
+	}
+	return time.AfterFunc(d, fn)
+}
+
+var (
+	_ TimerInterface = (*Clock)(nil)
+	_ TimerInterface = Native{}
+	_ TimerInterface = Stuck{}
+)
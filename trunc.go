@@ -0,0 +1,271 @@
+package clock
+
+import "time"
+
+// Step represents bucket size in calendar units, e.g. {15, Minute} or {1, Month}.
+type Step struct {
+	N    int
+	Unit Unit
+}
+
+// StartOf returns the beginning of calendar unit containing t in given location.
+//
+// Weeks start on Monday (ISO 8601). If the local midnight doesn't exist due to DST gap, the day starts at the first
+// instant after the gap; if the midnight repeats due to DST overlap, the earliest instant is used.
+// Nil location means location of t.
+func StartOf(t time.Time, unit Unit, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = t.Location()
+	}
+	t = t.In(loc)
+	y, m, d := t.Date()
+	switch unit {
+	case Nanosecond:
+		return t
+	case Microsecond, Millisecond, Second:
+		return t.Truncate(unit.Duration())
+	case Minute:
+		return t.Add(-time.Duration(t.Nanosecond()) - time.Duration(t.Second())*time.Second)
+	case Hour:
+		return t.Add(-time.Duration(t.Nanosecond()) - time.Duration(t.Second())*time.Second -
+			time.Duration(t.Minute())*time.Minute)
+	case Day:
+		return dateIn(y, m, d, 0, 0, 0, 0, loc)
+	case Week:
+		wd := int(t.Weekday()+6) % 7
+		return dateIn(y, m, d-wd, 0, 0, 0, 0, loc)
+	case Month:
+		return dateIn(y, m, 1, 0, 0, 0, 0, loc)
+	case Quarter:
+		return dateIn(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+	case Year:
+		return dateIn(y, 1, 1, 0, 0, 0, 0, loc)
+	case Century:
+		return dateIn(y-mod(y, 100), 1, 1, 0, 0, 0, 0, loc)
+	case Millennium:
+		return dateIn(y-mod(y, 1000), 1, 1, 0, 0, 0, 0, loc)
+	}
+	return t
+}
+
+// EndOf returns the last instant (nanosecond) of calendar unit containing t in given location.
+func EndOf(t time.Time, unit Unit, loc *time.Location) time.Time {
+	if unit == Nanosecond {
+		return t
+	}
+	return startOfShift(t, 1, unit, loc).Add(-1)
+}
+
+// startOfShift returns the beginning of calendar unit shifted by n units from unit containing t.
+func startOfShift(t time.Time, n int, unit Unit, loc *time.Location) time.Time {
+	s := StartOf(t, unit, loc)
+	if unit < Day {
+		return AddUnits(s, n, unit)
+	}
+	// Start of day may be moved by DST gap, so truncate again after shift.
+	return StartOf(AddUnits(s, n, unit), unit, loc)
+}
+
+// AddUnits adds n units to t.
+//
+// Units less than day are exact durations. Day and greater keep wall clock in location of t, months are clamped
+// to the last day of month (Jan 31 + 1 month = Feb 28/29).
+func AddUnits(t time.Time, n int, unit Unit) time.Time {
+	if unit < Day || unit > Millennium {
+		return t.Add(time.Duration(n) * unit.Duration())
+	}
+	y, m, d := t.Date()
+	hh, mm, ss := t.Clock()
+	switch unit {
+	case Day:
+		d += n
+	case Week:
+		d += n * 7
+	default:
+		var months int
+		switch unit {
+		case Month:
+			months = n
+		case Quarter:
+			months = n * 3
+		case Year:
+			months = n * 12
+		case Century:
+			months = n * 1200
+		case Millennium:
+			months = n * 12000
+		}
+		m += time.Month(months)
+		if last := daysIn(y, m); d > last {
+			d = last
+		}
+	}
+	return dateIn(y, m, d, hh, mm, ss, t.Nanosecond(), t.Location())
+}
+
+// Bucket returns interval of the bucket containing t.
+//
+// Buckets are origin + k*step. Steps less than day are exact durations, day and greater follow the calendar of given
+// location. Zero origin means 1970-01-01 00:00 in the location (Monday 1970-01-05 for weekly steps).
+func Bucket(t time.Time, step Step, origin time.Time, loc *time.Location) Interval {
+	origin, step, loc = bucketNorm(t, step, origin, loc)
+	k := bucketIndex(t.In(loc), step, origin)
+	return Interval{
+		Start: AddUnits(origin, k*step.N, step.Unit),
+		End:   AddUnits(origin, (k+1)*step.N, step.Unit),
+	}
+}
+
+// Buckets iterates over buckets overlapping the interval.
+//
+// Usage:
+//
+//	b := NewBuckets(iv, Step{1, Day}, time.Time{}, loc)
+//	for b.Next() {
+//		bucket := b.Interval()
+//	}
+type Buckets struct {
+	iv     Interval
+	step   Step
+	origin time.Time
+	k, n   int
+	cur    Interval
+}
+
+// NewBuckets makes iterator over buckets overlapping the interval.
+func NewBuckets(iv Interval, step Step, origin time.Time, loc *time.Location) *Buckets {
+	origin, step, loc = bucketNorm(iv.Start, step, origin, loc)
+	iv.Start, iv.End = iv.Start.In(loc), iv.End.In(loc)
+	return &Buckets{
+		iv:     iv,
+		step:   step,
+		origin: origin,
+		k:      bucketIndex(iv.Start, step, origin),
+	}
+}
+
+// Next moves iterator to the next bucket. Returns false if buckets are over.
+func (b *Buckets) Next() bool {
+	if b.iv.IsZero() {
+		return false
+	}
+	start := AddUnits(b.origin, (b.k+b.n)*b.step.N, b.step.Unit)
+	if !start.Before(b.iv.End) {
+		return false
+	}
+	b.cur = Interval{Start: start, End: AddUnits(b.origin, (b.k+b.n+1)*b.step.N, b.step.Unit)}
+	b.n++
+	return true
+}
+
+// Interval returns current bucket.
+func (b *Buckets) Interval() Interval {
+	return b.cur
+}
+
+func bucketNorm(t time.Time, step Step, origin time.Time, loc *time.Location) (time.Time, Step, *time.Location) {
+	if loc == nil {
+		loc = t.Location()
+	}
+	if step.N <= 0 {
+		step.N = 1
+	}
+	if step.Unit == UnitUnknown {
+		step.Unit = Nanosecond
+	}
+	if origin.IsZero() {
+		if step.Unit == Week {
+			origin = dateIn(1970, 1, 5, 0, 0, 0, 0, loc)
+		} else {
+			origin = dateIn(1970, 1, 1, 0, 0, 0, 0, loc)
+		}
+	}
+	return origin.In(loc), step, loc
+}
+
+// bucketIndex returns index k of bucket origin + k*step containing t.
+func bucketIndex(t time.Time, step Step, origin time.Time) int {
+	var k int
+	if step.Unit < Day {
+		size := time.Duration(step.N) * step.Unit.Duration()
+		diff := t.Sub(origin)
+		k = int(diff / size)
+		if diff < 0 && diff%size != 0 {
+			k--
+		}
+		return k
+	}
+	// Estimate index using calendar difference and fix it.
+	y0, m0, d0 := origin.Date()
+	y1, m1, d1 := t.Date()
+	var units int
+	switch step.Unit {
+	case Day, Week:
+		days := civilDays(y1, m1, d1) - civilDays(y0, m0, d0)
+		if step.Unit == Week {
+			days /= 7
+		}
+		units = days
+	default:
+		months := (y1-y0)*12 + int(m1-m0)
+		switch step.Unit {
+		case Quarter:
+			months /= 3
+		case Year:
+			months /= 12
+		case Century:
+			months /= 1200
+		case Millennium:
+			months /= 12000
+		}
+		units = months
+	}
+	k = units / step.N
+	for AddUnits(origin, k*step.N, step.Unit).After(t) {
+		k--
+	}
+	for !AddUnits(origin, (k+1)*step.N, step.Unit).After(t) {
+		k++
+	}
+	return k
+}
+
+// dateIn returns the earliest instant with given wall clock in the location.
+//
+// Unlike time.Date, which may move non-existent wall clock backward, the wall clock fallen into DST gap is moved
+// forward by the gap size (02:30 in 02:00-03:00 gap becomes 03:30).
+func dateIn(y int, m time.Month, d, hh, mm, ss, ns int, loc *time.Location) time.Time {
+	wall := time.Date(y, m, d, hh, mm, ss, ns, time.UTC)
+	var r time.Time
+	for _, probe := range [...]time.Duration{-day, 0, day} {
+		_, off := wall.Add(probe).In(loc).Zone()
+		c := wall.Add(-time.Duration(off) * time.Second).In(loc)
+		cy, cm, cd := c.Date()
+		ch, cmi, cs := c.Clock()
+		if time.Date(cy, cm, cd, ch, cmi, cs, c.Nanosecond(), time.UTC).Before(wall) {
+			continue
+		}
+		if r.IsZero() || c.Before(r) {
+			r = c
+		}
+	}
+	return r
+}
+
+// daysIn returns number of days in month (month may overflow).
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// civilDays returns number of days since 1970-01-01 for given date.
+func civilDays(y int, m time.Month, d int) int {
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+func mod(a, b int) int {
+	r := a % b
+	if r < 0 {
+		r += b
+	}
+	return r
+}
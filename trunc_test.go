@@ -0,0 +1,206 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoc(t testing.TB, name string) *time.Location {
+	loc, err := LoadLocation(name)
+	if err != nil {
+		t.Skipf("location %s not available: %s", name, err)
+	}
+	return loc
+}
+
+func TestStartOf(t *testing.T) {
+	t.Run("units", func(t *testing.T) {
+		loc := mustLoc(t, "Asia/Kolkata")
+		x := time.Date(2024, 8, 14, 17, 47, 33, 123456789, loc) // Wednesday
+		stages := []struct {
+			unit  Unit
+			start time.Time
+		}{
+			{Nanosecond, x},
+			{Millisecond, time.Date(2024, 8, 14, 17, 47, 33, 123000000, loc)},
+			{Second, time.Date(2024, 8, 14, 17, 47, 33, 0, loc)},
+			{Minute, time.Date(2024, 8, 14, 17, 47, 0, 0, loc)},
+			{Hour, time.Date(2024, 8, 14, 17, 0, 0, 0, loc)},
+			{Day, time.Date(2024, 8, 14, 0, 0, 0, 0, loc)},
+			{Week, time.Date(2024, 8, 12, 0, 0, 0, 0, loc)},
+			{Month, time.Date(2024, 8, 1, 0, 0, 0, 0, loc)},
+			{Quarter, time.Date(2024, 7, 1, 0, 0, 0, 0, loc)},
+			{Year, time.Date(2024, 1, 1, 0, 0, 0, 0, loc)},
+			{Century, time.Date(2000, 1, 1, 0, 0, 0, 0, loc)},
+		}
+		for _, st := range stages {
+			t.Run(st.unit.String(), func(t *testing.T) {
+				// Pass time in UTC to check that location is respected.
+				if r := StartOf(x.UTC(), st.unit, loc); !r.Equal(st.start) {
+					t.Errorf("start mismatch: need %s, got %s", st.start, r)
+				}
+			})
+		}
+	})
+	t.Run("dst", func(t *testing.T) {
+		ny := mustLoc(t, "America/New_York")
+		scl := mustLoc(t, "America/Santiago")
+		stages := []struct {
+			key        string
+			t          time.Time
+			loc        *time.Location
+			unit       Unit
+			start, end string
+		}{
+			{"gap day", time.Date(2024, 3, 10, 12, 0, 0, 0, ny), ny, Day,
+				"2024-03-10T00:00:00-05:00", "2024-03-10T23:59:59.999999999-04:00"},
+			{"overlap day", time.Date(2024, 11, 3, 12, 0, 0, 0, ny), ny, Day,
+				"2024-11-03T00:00:00-04:00", "2024-11-03T23:59:59.999999999-05:00"},
+			{"overlap first hour", time.Unix(1730611800, 0), ny, Hour, // 01:30 EDT
+				"2024-11-03T01:00:00-04:00", "2024-11-03T01:59:59.999999999-04:00"},
+			{"overlap second hour", time.Unix(1730615400, 0), ny, Hour, // 01:30 EST
+				"2024-11-03T01:00:00-05:00", "2024-11-03T01:59:59.999999999-05:00"},
+			{"midnight gap day", time.Date(2024, 9, 8, 10, 0, 0, 0, scl), scl, Day,
+				"2024-09-08T01:00:00-03:00", "2024-09-08T23:59:59.999999999-03:00"},
+			{"midnight gap month", time.Date(2024, 9, 8, 10, 0, 0, 0, scl), scl, Month,
+				"2024-09-01T00:00:00-04:00", "2024-09-30T23:59:59.999999999-03:00"},
+			{"midnight overlap day", time.Unix(1712460600, 0), scl, Day, // 2024-04-06 23:30 -04, second occurrence
+				"2024-04-06T00:00:00-03:00", "2024-04-06T23:59:59.999999999-04:00"},
+			{"midnight overlap next day", time.Date(2024, 4, 7, 10, 0, 0, 0, scl), scl, Day,
+				"2024-04-07T00:00:00-04:00", "2024-04-07T23:59:59.999999999-04:00"},
+		}
+		for _, st := range stages {
+			t.Run(st.key, func(t *testing.T) {
+				if s := StartOf(st.t, st.unit, st.loc).Format(time.RFC3339Nano); s != st.start {
+					t.Errorf("start mismatch: need %s, got %s", st.start, s)
+				}
+				if e := EndOf(st.t, st.unit, st.loc).Format(time.RFC3339Nano); e != st.end {
+					t.Errorf("end mismatch: need %s, got %s", st.end, e)
+				}
+			})
+		}
+	})
+	t.Run("exhaustive", func(t *testing.T) {
+		// Check invariants every 10 minutes around DST transitions.
+		names := []string{"America/New_York", "Europe/Berlin", "America/Santiago", "Australia/Lord_Howe", "Asia/Kolkata"}
+		ranges := [][2]time.Time{
+			{time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 9, 0, 0, 0, 0, time.UTC)},
+			{time.Date(2024, 9, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC)},
+		}
+		units := []Unit{Minute, Hour, Day, Week, Month}
+		for _, name := range names {
+			loc := mustLoc(t, name)
+			for _, r := range ranges {
+				for x := r[0]; x.Before(r[1]); x = x.Add(10 * time.Minute) {
+					for _, unit := range units {
+						s, e := StartOf(x, unit, loc), EndOf(x, unit, loc)
+						if s.After(x) || e.Before(x) {
+							t.Fatalf("%s %s %s: %s out of [%s, %s]", name, unit, x, x, s, e)
+						}
+						if s1 := StartOf(e, unit, loc); !s1.Equal(s) {
+							t.Fatalf("%s %s %s: start of end mismatch: %s != %s", name, unit, x, s1, s)
+						}
+						if s2 := StartOf(s.Add(-1), unit, loc); !s2.Before(s) {
+							t.Fatalf("%s %s %s: previous unit isn't before start", name, unit, x)
+						}
+						if unit >= Day {
+							xl := x.In(loc)
+							if s.In(loc).Day() != xl.Day() && unit == Day || e.In(loc).Day() != xl.Day() && unit == Day {
+								t.Fatalf("%s %s %s: day mismatch: [%s, %s]", name, unit, x, s, e)
+							}
+						}
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestAddUnits(t *testing.T) {
+	ny := mustLoc(t, "America/New_York")
+	stages := []struct {
+		key  string
+		t    time.Time
+		n    int
+		unit Unit
+		exp  string
+	}{
+		{"month clamp", time.Date(2024, 1, 31, 10, 0, 0, 0, ny), 1, Month, "2024-02-29T10:00:00-05:00"},
+		{"month clamp back", time.Date(2024, 3, 31, 10, 0, 0, 0, ny), -1, Month, "2024-02-29T10:00:00-05:00"},
+		{"year leap", time.Date(2024, 2, 29, 0, 0, 0, 0, ny), 1, Year, "2025-02-28T00:00:00-05:00"},
+		{"day over gap", time.Date(2024, 3, 9, 12, 0, 0, 0, ny), 1, Day, "2024-03-10T12:00:00-04:00"},
+		{"day into gap", time.Date(2024, 3, 9, 2, 30, 0, 0, ny), 1, Day, "2024-03-10T03:30:00-04:00"},
+		{"day into overlap", time.Date(2024, 11, 2, 1, 30, 0, 0, ny), 1, Day, "2024-11-03T01:30:00-04:00"},
+		{"hours over gap", time.Date(2024, 3, 10, 1, 0, 0, 0, ny), 2, Hour, "2024-03-10T04:00:00-04:00"},
+	}
+	for _, st := range stages {
+		t.Run(st.key, func(t *testing.T) {
+			if r := AddUnits(st.t, st.n, st.unit).Format(time.RFC3339); r != st.exp {
+				t.Errorf("need %s, got %s", st.exp, r)
+			}
+		})
+	}
+}
+
+func TestBucket(t *testing.T) {
+	ny := mustLoc(t, "America/New_York")
+	t.Run("daily dst", func(t *testing.T) {
+		b := Bucket(time.Date(2024, 3, 10, 15, 0, 0, 0, ny), Step{1, Day}, time.Time{}, ny)
+		if b.Duration() != 23*time.Hour || b.Start.In(ny).Hour() != 0 {
+			t.Errorf("bucket mismatch: %s", b)
+		}
+		b = Bucket(time.Date(2024, 11, 3, 15, 0, 0, 0, ny), Step{1, Day}, time.Time{}, ny)
+		if b.Duration() != 25*time.Hour {
+			t.Errorf("bucket mismatch: %s", b)
+		}
+	})
+	t.Run("origin", func(t *testing.T) {
+		origin := time.Date(2024, 1, 1, 9, 0, 0, 0, ny)
+		b := Bucket(time.Date(2024, 3, 10, 8, 0, 0, 0, ny), Step{2, Day}, origin, ny)
+		if s := b.String(); s != "2024-03-09T09:00:00-05:00/2024-03-11T09:00:00-04:00" {
+			t.Errorf("bucket mismatch: %s", s)
+		}
+		b = Bucket(time.Date(2023, 12, 31, 8, 0, 0, 0, ny), Step{2, Day}, origin, ny)
+		if s := b.String(); s != "2023-12-30T09:00:00-05:00/2024-01-01T09:00:00-05:00" {
+			t.Errorf("bucket mismatch: %s", s)
+		}
+	})
+	t.Run("sub-day", func(t *testing.T) {
+		loc := mustLoc(t, "Asia/Kolkata")
+		b := Bucket(time.Date(2024, 3, 10, 8, 20, 0, 0, loc), Step{15, Minute}, time.Time{}, loc)
+		if s := b.String(); s != "2024-03-10T08:15:00+05:30/2024-03-10T08:30:00+05:30" {
+			t.Errorf("bucket mismatch: %s", s)
+		}
+	})
+	t.Run("iterator", func(t *testing.T) {
+		iv := Interval{time.Date(2024, 3, 1, 0, 0, 0, 0, ny), time.Date(2024, 4, 1, 0, 0, 0, 0, ny)}
+		var (
+			n    int
+			prev time.Time
+		)
+		b := NewBuckets(iv, Step{1, Day}, time.Time{}, ny)
+		for b.Next() {
+			x := b.Interval()
+			if n > 0 && !x.Start.Equal(prev) {
+				t.Fatalf("bucket gap: %s != %s", x.Start, prev)
+			}
+			if x.Start.In(ny).Hour() != 0 {
+				t.Fatalf("bucket isn't aligned to midnight: %s", x)
+			}
+			prev = x.End
+			n++
+		}
+		if n != 31 {
+			t.Errorf("buckets count mismatch: need %d, got %d", 31, n)
+		}
+		n = 0
+		iv = Interval{time.Date(2024, 2, 15, 0, 0, 0, 0, ny), time.Date(2024, 11, 15, 0, 0, 0, 0, ny)}
+		b = NewBuckets(iv, Step{1, Quarter}, time.Time{}, ny)
+		for b.Next() {
+			n++
+		}
+		if n != 4 {
+			t.Errorf("buckets count mismatch: need %d, got %d", 4, n)
+		}
+	})
+}
@@ -0,0 +1,293 @@
+// Package ttl provides map with expiring items driven by clock.Interface.
+package ttl
+
+import (
+	"fmt"
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/koykov/clock"
+)
+
+// Mode is an expiry mode.
+type Mode uint8
+
+const (
+	// Lazy mode removes expired items on access only.
+	Lazy Mode = iota
+	// Active mode additionally sweeps expired items by clock scheduler (see clock.Scheduler).
+	Active
+)
+
+// Reason is a reason of item eviction.
+type Reason uint8
+
+const (
+	Expired Reason = iota
+	Deleted
+	Replaced
+)
+
+const defaultShards = 16
+
+// Options describes map settings.
+type Options struct {
+	// Default TTL of items. Zero means no expiry.
+	TTL time.Duration
+	// Sliding expiration: each successful Get extends item's life by its TTL.
+	Sliding bool
+	// Expiry mode.
+	Mode Mode
+	// Sweep interval of Active mode, default is TTL.
+	SweepInterval time.Duration
+	// Number of shards, default is 16.
+	Shards int
+}
+
+// Map is a concurrent map with expiring items. Expiry is checked against given clock, so maps over controllable
+// clock (clock.Clock with Jump) may be tested deterministically.
+type Map[K comparable, V any] struct {
+	// Hash is a hash function of keys. Default hash supports strings, integers and falls back to fmt for other
+	// types. Custom hash must be set before use.
+	Hash func(key K) uint64
+	// OnEvict is called on eviction of item outside of map locks. Must be set before use.
+	OnEvict func(key K, val V, reason Reason)
+
+	clock  clock.Interface
+	opts   Options
+	shards []shard[K, V]
+	closed uint32
+	// Sweep job handle of active mode.
+	sweep clock.Timer
+}
+
+type shard[K comparable, V any] struct {
+	mux sync.RWMutex
+	buf map[K]*item[V]
+}
+
+type item[V any] struct {
+	// Atomic fields go first to keep 64-bit alignment on 32-bit platforms.
+	// Expiration time (ns), zero means no expiry. Updated atomically by sliding expiration.
+	exp int64
+	ttl int64
+	val V
+}
+
+type eviction[K comparable, V any] struct {
+	key K
+	val V
+}
+
+// New makes new map over clock c. Nil c means clock.Native.
+// Active mode registers sweep job in clock scheduler if c implements clock.Scheduler; otherwise, call Sweep
+// periodically.
+func New[K comparable, V any](c clock.Interface, opts Options) *Map[K, V] {
+	if c == nil {
+		c = clock.Native{}
+	}
+	if opts.Shards <= 0 {
+		opts.Shards = defaultShards
+	}
+	if opts.SweepInterval <= 0 {
+		opts.SweepInterval = opts.TTL
+	}
+	m := &Map[K, V]{
+		Hash:   defaultHash[K](maphash.MakeSeed()),
+		clock:  c,
+		opts:   opts,
+		shards: make([]shard[K, V], opts.Shards),
+	}
+	for i := range m.shards {
+		m.shards[i].buf = make(map[K]*item[V])
+	}
+	if s, ok := c.(clock.Scheduler); ok && opts.Mode == Active && opts.SweepInterval > 0 {
+		m.sweep = s.Every(opts.SweepInterval, m.Sweep)
+	}
+	return m
+}
+
+// Set sets value of key with default TTL.
+func (m *Map[K, V]) Set(key K, val V) {
+	m.SetWithTTL(key, val, m.opts.TTL)
+}
+
+// SetWithTTL sets value of key with given TTL. Zero TTL means no expiry.
+func (m *Map[K, V]) SetWithTTL(key K, val V, ttl time.Duration) {
+	it := &item[V]{val: val, ttl: int64(ttl)}
+	if ttl > 0 {
+		it.exp = m.now() + int64(ttl)
+	}
+	s := m.shard(key)
+	s.mux.Lock()
+	old, ok := s.buf[key]
+	s.buf[key] = it
+	s.mux.Unlock()
+	if ok && m.OnEvict != nil {
+		m.OnEvict(key, old.val, Replaced)
+	}
+}
+
+// Get returns value of key. Expired item is evicted.
+func (m *Map[K, V]) Get(key K) (val V, ok bool) {
+	now := m.now()
+	s := m.shard(key)
+	s.mux.RLock()
+	it, ok := s.buf[key]
+	if ok && it.expired(now) {
+		ok = false
+	}
+	if ok {
+		val = it.val
+		if m.opts.Sliding && it.ttl > 0 {
+			atomic.StoreInt64(&it.exp, now+it.ttl)
+		}
+	}
+	s.mux.RUnlock()
+	if it != nil && !ok {
+		m.expire(s, key, it, now)
+	}
+	return
+}
+
+// Delete deletes key.
+func (m *Map[K, V]) Delete(key K) {
+	s := m.shard(key)
+	s.mux.Lock()
+	it, ok := s.buf[key]
+	delete(s.buf, key)
+	s.mux.Unlock()
+	if ok && m.OnEvict != nil {
+		m.OnEvict(key, it.val, Deleted)
+	}
+}
+
+// Len returns number of items including expired but not evicted yet.
+func (m *Map[K, V]) Len() (n int) {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mux.RLock()
+		n += len(s.buf)
+		s.mux.RUnlock()
+	}
+	return
+}
+
+// Range calls fn for each live item until fn returns false.
+func (m *Map[K, V]) Range(fn func(key K, val V) bool) {
+	now := m.now()
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mux.RLock()
+		for k, it := range s.buf {
+			if !it.expired(now) && !fn(k, it.val) {
+				s.mux.RUnlock()
+				return
+			}
+		}
+		s.mux.RUnlock()
+	}
+}
+
+// Sweep evicts expired items.
+func (m *Map[K, V]) Sweep() {
+	if atomic.LoadUint32(&m.closed) == 1 {
+		return
+	}
+	now := m.now()
+	var buf []eviction[K, V]
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mux.Lock()
+		for k, it := range s.buf {
+			if it.expired(now) {
+				delete(s.buf, k)
+				buf = append(buf, eviction[K, V]{key: k, val: it.val})
+			}
+		}
+		s.mux.Unlock()
+	}
+	if m.OnEvict != nil {
+		for _, e := range buf {
+			m.OnEvict(e.key, e.val, Expired)
+		}
+	}
+}
+
+// Close stops active sweeping and unregisters sweep job.
+func (m *Map[K, V]) Close() {
+	atomic.StoreUint32(&m.closed, 1)
+	if m.sweep != nil {
+		m.sweep.Stop()
+	}
+}
+
+func (m *Map[K, V]) expire(s *shard[K, V], key K, it *item[V], now int64) {
+	s.mux.Lock()
+	// Item may be replaced or extended meanwhile.
+	cur, ok := s.buf[key]
+	if ok = ok && cur == it && it.expired(now); ok {
+		delete(s.buf, key)
+	}
+	s.mux.Unlock()
+	if ok && m.OnEvict != nil {
+		m.OnEvict(key, it.val, Expired)
+	}
+}
+
+func (m *Map[K, V]) shard(key K) *shard[K, V] {
+	if len(m.shards) == 1 {
+		return &m.shards[0]
+	}
+	return &m.shards[m.Hash(key)%uint64(len(m.shards))]
+}
+
+// defaultHash returns hash function of K. Known types are hashed without boxing of keys.
+func defaultHash[K comparable](seed maphash.Seed) func(key K) uint64 {
+	var zero K
+	switch any(zero).(type) {
+	case string:
+		return func(key K) uint64 {
+			var h maphash.Hash
+			h.SetSeed(seed)
+			_, _ = h.WriteString(*(*string)(unsafe.Pointer(&key)))
+			return h.Sum64()
+		}
+	case int, uint, uintptr:
+		if unsafe.Sizeof(zero) == 4 {
+			return func(key K) uint64 { return mix(uint64(*(*uint32)(unsafe.Pointer(&key)))) }
+		}
+		return func(key K) uint64 { return mix(*(*uint64)(unsafe.Pointer(&key))) }
+	case int64, uint64:
+		return func(key K) uint64 { return mix(*(*uint64)(unsafe.Pointer(&key))) }
+	case int32, uint32:
+		return func(key K) uint64 { return mix(uint64(*(*uint32)(unsafe.Pointer(&key)))) }
+	}
+	return func(key K) uint64 {
+		var h maphash.Hash
+		h.SetSeed(seed)
+		_, _ = fmt.Fprint(&h, key)
+		return h.Sum64()
+	}
+}
+
+// mix is a splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+func (m *Map[K, V]) now() int64 {
+	return m.clock.Now().UnixNano()
+}
+
+func (it *item[V]) expired(now int64) bool {
+	exp := atomic.LoadInt64(&it.exp)
+	return exp != 0 && now >= exp
+}
@@ -0,0 +1,141 @@
+package ttl
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/koykov/clock"
+)
+
+func newClock() *clock.Clock {
+	c := clock.NewClock()
+	c.SetRate(0)
+	return c
+}
+
+func TestMap(t *testing.T) {
+	t.Run("lazy", func(t *testing.T) {
+		c := newClock()
+		m := New[string, int](c, Options{TTL: time.Minute})
+		var evicted []string
+		m.OnEvict = func(key string, _ int, reason Reason) {
+			if reason == Expired {
+				evicted = append(evicted, key)
+			}
+		}
+		m.Set("a", 1)
+		m.SetWithTTL("b", 2, time.Hour)
+		m.SetWithTTL("c", 3, 0)
+		c.Jump(2 * time.Minute)
+		if _, ok := m.Get("a"); ok {
+			t.Error("item must expire")
+		}
+		if v, ok := m.Get("b"); !ok || v != 2 {
+			t.Error("item with own TTL must live")
+		}
+		if m.Len() != 2 || len(evicted) != 1 {
+			t.Errorf("lazy mode must evict on access: len %d, evicted %v", m.Len(), evicted)
+		}
+		c.Jump(24 * time.Hour)
+		if _, ok := m.Get("c"); !ok {
+			t.Error("item without TTL must live forever")
+		}
+	})
+	t.Run("active", func(t *testing.T) {
+		c := newClock()
+		m := New[int, string](c, Options{TTL: time.Minute, Mode: Active, SweepInterval: 10 * time.Second})
+		var (
+			mux     sync.Mutex
+			evicted int
+		)
+		m.OnEvict = func(int, string, Reason) {
+			mux.Lock()
+			evicted++
+			mux.Unlock()
+		}
+		for i := 0; i < 100; i++ {
+			m.Set(i, strconv.Itoa(i))
+		}
+		c.Jump(30 * time.Second)
+		if n := m.Len(); n != 100 {
+			t.Fatalf("items must live: %d", n)
+		}
+		c.Jump(31 * time.Second)
+		if n := m.Len(); n != 0 || evicted != 100 {
+			t.Errorf("active mode must sweep: len %d, evicted %d", n, evicted)
+		}
+		m.Close()
+		if n := c.Stats().Jobs; n != 0 {
+			t.Errorf("close must unregister sweep job: %d jobs", n)
+		}
+	})
+	t.Run("sliding", func(t *testing.T) {
+		c := newClock()
+		m := New[string, int](c, Options{TTL: time.Minute, Sliding: true, Shards: 1})
+		m.Set("a", 1)
+		for i := 0; i < 5; i++ {
+			c.Jump(50 * time.Second)
+			if _, ok := m.Get("a"); !ok {
+				t.Fatal("sliding item must live while accessed")
+			}
+		}
+		c.Jump(61 * time.Second)
+		if _, ok := m.Get("a"); ok {
+			t.Error("idle sliding item must expire")
+		}
+	})
+	t.Run("callbacks", func(t *testing.T) {
+		m := New[string, int](nil, Options{})
+		var reasons []Reason
+		m.OnEvict = func(_ string, _ int, reason Reason) { reasons = append(reasons, reason) }
+		m.Set("a", 1)
+		m.Set("a", 2)
+		m.Delete("a")
+		if len(reasons) != 2 || reasons[0] != Replaced || reasons[1] != Deleted {
+			t.Errorf("reasons mismatch: %v", reasons)
+		}
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		c := clock.NewClock()
+		c.Start()
+		defer c.Stop()
+		m := New[string, int](c, Options{TTL: time.Millisecond, Mode: Active})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					k := strconv.Itoa(j % 50)
+					m.Set(k, j)
+					m.Get(k)
+					if j%10 == 0 {
+						m.Delete(k)
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		m.Close()
+	})
+}
+
+func BenchmarkMap(b *testing.B) {
+	c := clock.NewClock()
+	c.Start()
+	defer c.Stop()
+	m := New[int, int](c, Options{TTL: time.Minute})
+	for i := 0; i < 1024; i++ {
+		m.Set(i, i)
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			m.Get(i & 1023)
+			i++
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package clock
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordDec = func() (t [256]byte) {
+	for i := range t {
+		t[i] = 0xff
+	}
+	for i := 0; i < len(crockford); i++ {
+		t[crockford[i]] = byte(i)
+		t[crockford[i]|0x20] = byte(i)
+	}
+	return
+}()
+
+// ULID is an Universally Unique Lexicographically Sortable Identifier: 48 bits of Unix time in milliseconds
+// followed by 80 random bits.
+type ULID [16]byte
+
+// Time returns time part of ULID.
+func (u ULID) Time() time.Time {
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(u[:8]) >> 16))
+}
+
+// AppendText appends 26-chars Crockford's base32 form of ULID to dst.
+func (u ULID) AppendText(dst []byte) []byte {
+	var buf [26]byte
+	hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return append(dst, buf[:]...)
+}
+
+func (u ULID) String() string {
+	return string(u.AppendText(nil))
+}
+
+// ParseULID parses ULID in Crockford's base32 form (case insensitive).
+func ParseULID(raw string) (u ULID, err error) {
+	if len(raw) != 26 || raw[0] > '7' {
+		return u, ErrBadULID
+	}
+	var hi, lo uint64
+	for i := 0; i < len(raw); i++ {
+		v := crockfordDec[raw[i]]
+		if v == 0xff {
+			return u, ErrBadULID
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return
+}
+
+// ULIDGenerator is a generator of monotonic ULIDs: ULIDs within one millisecond increment random part of the
+// previous one. Config fields must be set before the first ID.
+type ULIDGenerator struct {
+	// Random part overflow policy.
+	Overflow IDOverflow
+	// Tolerated clock rollback: IDs continue from the last time. Bigger rollbacks fail with ErrClockRollback.
+	MaxRollback time.Duration
+
+	clock Interface
+	mux   sync.Mutex
+	ts    idClock
+	last  ULID
+	rnd   randBuf
+}
+
+// NewULIDGenerator makes new ULID generator over given clock. Nil clock means Native.
+func NewULIDGenerator(clock Interface) *ULIDGenerator {
+	if clock == nil {
+		clock = Native{}
+	}
+	return &ULIDGenerator{clock: clock}
+}
+
+// Next returns the next ULID.
+func (g *ULIDGenerator) Next() (u ULID, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	tick, same, err := g.ts.tick(g.now(), g.MaxRollback.Milliseconds())
+	if err != nil {
+		return u, err
+	}
+	if same && !incr80(&g.last) {
+		same = false
+		if tick, err = g.ts.advance(g.Overflow, g.now); err != nil {
+			return u, err
+		}
+	}
+	if !same {
+		g.rnd.read(g.last[6:])
+	}
+	if tick >= 1<<48 {
+		return u, ErrIDOverflow
+	}
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(tick)<<16)
+	copy(g.last[:6], ts[:6])
+	return g.last, nil
+}
+
+// Append appends text form of the next ULID to dst.
+func (g *ULIDGenerator) Append(dst []byte) ([]byte, error) {
+	u, err := g.Next()
+	if err != nil {
+		return dst, err
+	}
+	return u.AppendText(dst), nil
+}
+
+func (g *ULIDGenerator) now() int64 {
+	return g.clock.Now().UnixMilli()
+}
+
+// incr80 increments 80-bit random part of ULID. False means overflow.
+func incr80(u *ULID) bool {
+	for i := len(u) - 1; i >= 6; i-- {
+		if u[i]++; u[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package clock
+
+import (
+	"math"
+	"time"
+)
+
+// Unit represents time span unit.
+type Unit uint8
+
+const (
+	UnitUnknown Unit = iota
+	Nanosecond
+	Microsecond
+	Millisecond
+	Second
+	Minute
+	Hour
+	Day
+	Week
+	Month
+	Quarter
+	Year
+	Century
+	Millennium
+)
+
+const day = 24 * time.Hour
+
+var (
+	unitDur = [...]time.Duration{
+		Nanosecond:  time.Nanosecond,
+		Microsecond: time.Microsecond,
+		Millisecond: time.Millisecond,
+		Second:      time.Second,
+		Minute:      time.Minute,
+		Hour:        time.Hour,
+		Day:         day,
+		Week:        7 * day,
+		Month:       day*30 + day*44/100,
+		Quarter:     3 * (day*30 + day*44/100),
+		Year:        day*365 + day*1/4,
+		Century:     day * 36525,
+		Millennium:  math.MaxInt64,
+	}
+	unitName = [...]string{
+		UnitUnknown: "unknown",
+		Nanosecond:  "nanosecond",
+		Microsecond: "microsecond",
+		Millisecond: "millisecond",
+		Second:      "second",
+		Minute:      "minute",
+		Hour:        "hour",
+		Day:         "day",
+		Week:        "week",
+		Month:       "month",
+		Quarter:     "quarter",
+		Year:        "year",
+		Century:     "century",
+		Millennium:  "millennium",
+	}
+)
+
+// Duration returns duration of the unit.
+// Calendar units (month and greater) return average duration.
+// Millennium doesn't fit time.Duration and saturates to its maximum value.
+func (u Unit) Duration() time.Duration {
+	if int(u) >= len(unitDur) {
+		return 0
+	}
+	return unitDur[u]
+}
+
+// Calendar checks if unit has variable length and can't be represented as exact duration.
+func (u Unit) Calendar() bool {
+	return u >= Month && u <= Millennium
+}
+
+func (u Unit) String() string {
+	if int(u) >= len(unitName) {
+		return unitName[UnitUnknown]
+	}
+	return unitName[u]
+}
@@ -0,0 +1,123 @@
+package clock
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// UUID is an RFC 9562 UUID.
+type UUID [16]byte
+
+// Time returns time part of UUIDv7.
+func (u UUID) Time() time.Time {
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(u[:8]) >> 16))
+}
+
+// Version returns UUID version.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// AppendText appends canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx of UUID to dst.
+func (u UUID) AppendText(dst []byte) []byte {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return append(dst, buf[:]...)
+}
+
+func (u UUID) String() string {
+	return string(u.AppendText(nil))
+}
+
+// ParseUUID parses UUID in canonical form.
+func ParseUUID(raw string) (u UUID, err error) {
+	if len(raw) != 36 || raw[8] != '-' || raw[13] != '-' || raw[18] != '-' || raw[23] != '-' {
+		return u, ErrBadUUID
+	}
+	var buf [32]byte
+	copy(buf[0:8], raw[0:8])
+	copy(buf[8:12], raw[9:13])
+	copy(buf[12:16], raw[14:18])
+	copy(buf[16:20], raw[19:23])
+	copy(buf[20:], raw[24:])
+	if _, err = hex.Decode(u[:], buf[:]); err != nil {
+		return u, ErrBadUUID
+	}
+	return
+}
+
+// UUIDv7Generator is a generator of time-ordered UUIDv7: 48 bits of Unix time in milliseconds, 12-bit counter in
+// rand_a field (RFC 9562, method 1) and 62 random bits. Config fields must be set before the first ID.
+type UUIDv7Generator struct {
+	// Counter overflow policy.
+	Overflow IDOverflow
+	// Tolerated clock rollback: IDs continue from the last time. Bigger rollbacks fail with ErrClockRollback.
+	MaxRollback time.Duration
+
+	clock Interface
+	mux   sync.Mutex
+	ts    idClock
+	seq   uint16
+	rnd   randBuf
+}
+
+// NewUUIDv7Generator makes new UUIDv7 generator over given clock. Nil clock means Native.
+func NewUUIDv7Generator(clock Interface) *UUIDv7Generator {
+	if clock == nil {
+		clock = Native{}
+	}
+	return &UUIDv7Generator{clock: clock}
+}
+
+// Next returns the next UUID.
+func (g *UUIDv7Generator) Next() (u UUID, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	tick, same, err := g.ts.tick(g.now(), g.MaxRollback.Milliseconds())
+	if err != nil {
+		return u, err
+	}
+	if same {
+		if g.seq++; g.seq >= 1<<12 {
+			same = false
+			if tick, err = g.ts.advance(g.Overflow, g.now); err != nil {
+				return u, err
+			}
+		}
+	}
+	g.rnd.read(u[8:])
+	if !same {
+		// Random start with the highest bit clear leaves room for counter.
+		g.seq = binary.BigEndian.Uint16(u[8:10]) & (1<<11 - 1)
+		g.rnd.read(u[8:10])
+	}
+	if tick >= 1<<48 {
+		return u, ErrIDOverflow
+	}
+	binary.BigEndian.PutUint64(u[:8], uint64(tick)<<16|0x7000|uint64(g.seq))
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// Append appends canonical form of the next UUID to dst.
+func (g *UUIDv7Generator) Append(dst []byte) ([]byte, error) {
+	u, err := g.Next()
+	if err != nil {
+		return dst, err
+	}
+	return u.AppendText(dst), nil
+}
+
+func (g *UUIDv7Generator) now() int64 {
+	return g.clock.Now().UnixMilli()
+}